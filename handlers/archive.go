@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// ArchiveHandler shows the archived projects and sessions
+func (h *Handler) ArchiveHandler(c echo.Context) error {
+	listing, err := h.archive.GetArchived()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load archive: "+err.Error())
+	}
+
+	projects, err := h.sessions.GetAllProjects()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load projects: "+err.Error())
+	}
+
+	retention, err := h.archive.GetRetention()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load retention rules: "+err.Error())
+	}
+
+	return c.Render(http.StatusOK, "archive.html", map[string]interface{}{
+		"Archive":   listing,
+		"Projects":  projects,
+		"Retention": retention,
+	})
+}
+
+// GetArchiveAPIHandler returns the archived projects and sessions as JSON
+func (h *Handler) GetArchiveAPIHandler(c echo.Context) error {
+	listing, err := h.archive.GetArchived()
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, listing)
+}
+
+// ArchiveSessionAPIHandler archives a session (PUT) or restores it (DELETE)
+func (h *Handler) ArchiveSessionAPIHandler(c echo.Context) error {
+	archived := c.Request().Method != http.MethodDelete
+
+	changed, err := h.archive.SetSessionsArchived([]string{c.Param("sessionId")}, archived)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"archived": archived,
+		"changed":  len(changed) > 0,
+	})
+}
+
+// ArchiveProjectAPIHandler archives a project (PUT) or restores it (DELETE)
+func (h *Handler) ArchiveProjectAPIHandler(c echo.Context) error {
+	archived := c.Request().Method != http.MethodDelete
+
+	changed, err := h.archive.SetProjectArchived(c.Param("encodedPath"), archived)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"archived": archived,
+		"changed":  changed,
+	})
+}
+
+// bulkArchiveRequest is the body of the bulk archive endpoint. Archived
+// defaults to true; before is a date (YYYY-MM-DD) or an RFC 3339 time.
+type bulkArchiveRequest struct {
+	Archived    *bool    `json:"archived"`
+	SessionIDs  []string `json:"sessionIds"`
+	EncodedPath string   `json:"encodedPath"`
+	Before      string   `json:"before"`
+	Query       string   `json:"query"`
+	DryRun      bool     `json:"dryRun"`
+}
+
+// BulkArchiveAPIHandler archives or restores every session matching the
+// selection. With dryRun it only returns the sessions that would change.
+func (h *Handler) BulkArchiveAPIHandler(c echo.Context) error {
+	var req bulkArchiveRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	archived := req.Archived == nil || *req.Archived
+	selection := models.ArchiveSelection{
+		SessionIDs:  req.SessionIDs,
+		EncodedPath: req.EncodedPath,
+		Query:       req.Query,
+	}
+	if req.Before != "" {
+		before, err := parseDate(req.Before)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid before date: " + req.Before})
+		}
+		selection.Before = before
+	}
+
+	sessions, err := h.archive.SelectSessions(selection, archived)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	ids := []string{}
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	if !req.DryRun {
+		if ids, err = h.archive.SetSessionsArchived(ids, archived); err != nil {
+			return apiError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"archived":   archived,
+		"dryRun":     req.DryRun,
+		"count":      len(ids),
+		"sessionIds": ids,
+		"sessions":   sessions,
+	})
+}
+
+// parseDate accepts a calendar date in local time or an RFC 3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
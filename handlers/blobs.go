@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// GetBlobHandler serves an image of a session by the SHA-256 of its content,
+// or its thumbnail with ?thumb=1
+func (h *Handler) GetBlobHandler(c echo.Context) error {
+	path, contentType, err := h.sessions.OpenBlob(c.Param("hash"), c.QueryParam("thumb") == "1")
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	// Blobs never change, since they are named by their content
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	return c.File(path)
+}
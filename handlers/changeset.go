@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSessionChangesAPIHandler returns the net change a session made to every
+// file it edited as JSON
+func (h *Handler) GetSessionChangesAPIHandler(c echo.Context) error {
+	changeset, err := h.sessions.GetSessionChangeset(c.Param("encodedPath"), c.Param("sessionId"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, changeset)
+}
+
+// GetSessionPatchHandler downloads the changes of a session as a patch that
+// can be applied to another checkout with git apply
+func (h *Handler) GetSessionPatchHandler(c echo.Context) error {
+	sessionID := c.Param("sessionId")
+	changeset, err := h.sessions.GetSessionChangeset(c.Param("encodedPath"), sessionID)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+sessionID+`.patch"`)
+	return c.Blob(http.StatusOK, "text/x-patch; charset=utf-8", []byte(changeset.Patch))
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// FilesHandler shows the files touched in a project's sessions
+func (h *Handler) FilesHandler(c echo.Context) error {
+	projects, err := h.sessions.GetAllProjects()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load projects: "+err.Error())
+	}
+
+	project := c.QueryParam("project")
+	if project == "" && len(projects) > 0 {
+		project = projects[0].EncodedPath
+	}
+
+	files := []models.FileActivity{}
+	if project != "" {
+		if files, err = h.sessions.GetProjectFiles(project, c.QueryParam("q")); err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to load files: "+err.Error())
+		}
+	}
+
+	return c.Render(http.StatusOK, "files.html", map[string]interface{}{
+		"Projects": projects,
+		"Project":  project,
+		"Query":    c.QueryParam("q"),
+		"Files":    files,
+	})
+}
+
+// GetProjectFilesAPIHandler returns the files read or edited in a project's
+// sessions as JSON, filtered by the q query parameter
+func (h *Handler) GetProjectFilesAPIHandler(c echo.Context) error {
+	files, err := h.sessions.GetProjectFiles(c.Param("encodedPath"), c.QueryParam("q"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, files)
+}
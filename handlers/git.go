@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+	"github.com/yugo-ibuki/claude-code-prompt-share/services"
+)
+
+// CommitsHandler shows the git commits of a project with the sessions that
+// made them
+func (h *Handler) CommitsHandler(c echo.Context) error {
+	projects, err := h.sessions.GetAllProjects()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load projects: "+err.Error())
+	}
+
+	project := c.QueryParam("project")
+	if project == "" && len(projects) > 0 {
+		project = projects[0].EncodedPath
+	}
+
+	commits := []models.Commit{}
+	notRepo := false
+	if project != "" {
+		commits, err = h.sessions.GetProjectCommits(project)
+		if errors.Is(err, services.ErrNotRepo) {
+			notRepo = true
+		} else if errors.Is(err, services.ErrNotFound) {
+			return c.String(http.StatusNotFound, "Project not found: "+project)
+		} else if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to load commits: "+err.Error())
+		}
+	}
+
+	return c.Render(http.StatusOK, "commits.html", map[string]interface{}{
+		"Projects": projects,
+		"Project":  project,
+		"Commit":   c.QueryParam("commit"),
+		"Commits":  commits,
+		"NotRepo":  notRepo,
+	})
+}
+
+// GetProjectCommitsAPIHandler returns the git commits of a project since its
+// first session, with the sessions each was made in
+func (h *Handler) GetProjectCommitsAPIHandler(c echo.Context) error {
+	commits, err := h.sessions.GetProjectCommits(c.Param("encodedPath"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, commits)
+}
+
+// GetSessionCommitsAPIHandler returns the git commits made during a session
+func (h *Handler) GetSessionCommitsAPIHandler(c echo.Context) error {
+	commits, err := h.sessions.GetSessionCommits(c.Param("encodedPath"), c.Param("sessionId"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, commits)
+}
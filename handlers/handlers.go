@@ -1,132 +1,171 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
 	"github.com/yugo-ibuki/claude-code-prompt-share/services"
 )
 
 type Handler struct {
-	sessionService *services.SessionService
+	sessions ProjectService
+	archive  ArchiveService
+	trash    TrashService
+	library  LibraryService
+	commands CommandService
+	stats    StatsService
 }
 
-func NewHandler() *Handler {
+// NewHandler returns the handlers, by default for the sessions in ~/.claude.
+// It fails when the sessions cannot be read, rather than on every request.
+func NewHandler(opts ...Option) (*Handler, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.service != nil {
+		return newHandler(o.service), nil
+	}
+	service, err := o.newSessionService()
+	if err != nil {
+		return nil, err
+	}
+	return newHandler(service), nil
+}
+
+// newHandler returns handlers that each use the part of service they need
+func newHandler(service SessionService) *Handler {
 	return &Handler{
-		sessionService: services.NewSessionService(),
+		sessions: service,
+		archive:  service,
+		trash:    service,
+		library:  service,
+		commands: service,
+		stats:    service,
 	}
 }
 
 // IndexHandler shows the main 3-column layout
 func (h *Handler) IndexHandler(c echo.Context) error {
-	projects, err := h.sessionService.GetAllProjects()
+	projects, err := h.sessions.GetAllProjects()
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to load projects: "+err.Error())
 	}
 
 	return c.Render(http.StatusOK, "index.html", map[string]interface{}{
 		"Projects": projects,
+		"ReadOnly": h.sessions.ReadOnly(),
 	})
 }
 
 // GetProjectsAPIHandler returns all projects as JSON
 func (h *Handler) GetProjectsAPIHandler(c echo.Context) error {
-	projects, err := h.sessionService.GetAllProjects()
+	projects, err := h.sessions.GetAllProjects()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, projects)
 }
 
-// GetSessionsAPIHandler returns all sessions for a project as JSON
+// GetSessionsAPIHandler returns all sessions for a project as JSON,
+// optionally only those with the tag given in the tag query parameter or
+// that checked out the git branch given in the branch query parameter
 func (h *Handler) GetSessionsAPIHandler(c echo.Context) error {
 	encodedPath := c.Param("encodedPath")
-	sessions, err := h.sessionService.GetProjectSessionsInfo(encodedPath)
+	sessions, err := h.sessions.GetProjectSessionsInfo(encodedPath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
+
+	if tag := c.QueryParam("tag"); tag != "" {
+		filtered := []models.SessionInfo{}
+		for _, session := range sessions {
+			if slices.Contains(session.Tags, tag) {
+				filtered = append(filtered, session)
+			}
+		}
+		sessions = filtered
+	}
+	if branch := c.QueryParam("branch"); branch != "" {
+		filtered := []models.SessionInfo{}
+		for _, session := range sessions {
+			if slices.Contains(session.GitBranches, branch) {
+				filtered = append(filtered, session)
+			}
+		}
+		sessions = filtered
+	}
 	return c.JSON(http.StatusOK, sessions)
 }
 
+// sessionAnnotationRequest is the body of the session annotation endpoint
+type sessionAnnotationRequest struct {
+	Title *string   `json:"title"`
+	Tags  *[]string `json:"tags"`
+}
+
+// UpdateSessionAnnotationHandler sets the title and tags of a session
+func (h *Handler) UpdateSessionAnnotationHandler(c echo.Context) error {
+	var req sessionAnnotationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	annotation, err := h.sessions.SetSessionAnnotation(c.Param("sessionId"), req.Title, req.Tags)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, annotation)
+}
+
+// GetSessionTagsAPIHandler returns every session tag with its usage count
+func (h *Handler) GetSessionTagsAPIHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.sessions.SessionTags())
+}
+
 // GetPromptsAPIHandler returns conversation threads (grouped prompts) for a session as JSON
 func (h *Handler) GetPromptsAPIHandler(c echo.Context) error {
 	encodedPath := c.Param("encodedPath")
 	sessionID := c.Param("sessionId")
 
-	session, err := h.sessionService.GetSession(encodedPath, sessionID)
+	turns, err := h.sessions.GetTurns(encodedPath, sessionID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	// Group messages into conversation threads
+	// Group turns into conversation threads. Prompts that got no answer are
+	// kept in the same thread as the prompt that was eventually answered.
 	var threads []map[string]interface{}
-	var currentThread map[string]interface{}
 	var threadPrompts []map[string]interface{}
 
-	for i, msg := range session.Messages {
-		if msg.Role == "user" {
-			// Skip empty or whitespace-only prompts
-			content := strings.TrimSpace(msg.Content)
-			if content == "" {
-				continue
-			}
-
-			// Add prompt to current thread
-			threadPrompts = append(threadPrompts, map[string]interface{}{
-				"index":     i,
-				"uuid":      msg.UUID,
-				"content":   content,
-				"timestamp": msg.Timestamp,
-			})
-		} else if msg.Role == "assistant" && len(threadPrompts) > 0 {
-			// Create thread when we hit an assistant response
-			firstPrompt := threadPrompts[0]
-			lastPrompt := threadPrompts[len(threadPrompts)-1]
-
-			// Create thread summary
-			summary := firstPrompt["content"].(string)
-			if len(summary) > 80 {
-				summary = summary[:80] + "..."
-			}
-
-			currentThread = map[string]interface{}{
-				"id":           fmt.Sprintf("thread-%d", len(threads)),
-				"firstIndex":   firstPrompt["index"],
-				"promptCount":  len(threadPrompts),
-				"prompts":      threadPrompts,
-				"summary":      summary,
-				"startTime":    firstPrompt["timestamp"],
-				"endTime":      lastPrompt["timestamp"],
-			}
+	for _, turn := range turns {
+		threadPrompts = append(threadPrompts, map[string]interface{}{
+			"uuid":          turn.Prompt.UUID,
+			"kind":          turn.Prompt.Kind,
+			"command":       turn.Prompt.Command,
+			"content":       strings.TrimSpace(services.PromptText(turn.Prompt)),
+			"timestamp":     turn.Prompt.Timestamp,
+			"endTime":       turn.EndTime,
+			"messageCount":  len(turn.Messages),
+			"toolCallCount": len(turn.ToolCalls),
+		})
 
-			threads = append(threads, currentThread)
+		if len(turn.Messages) > 0 {
+			threads = append(threads, newThread(len(threads), threadPrompts))
 			threadPrompts = []map[string]interface{}{}
 		}
 	}
 
 	// Add remaining prompts as a thread if any
 	if len(threadPrompts) > 0 {
-		firstPrompt := threadPrompts[0]
-		lastPrompt := threadPrompts[len(threadPrompts)-1]
-
-		summary := firstPrompt["content"].(string)
-		if len(summary) > 80 {
-			summary = summary[:80] + "..."
-		}
-
-		currentThread = map[string]interface{}{
-			"id":          fmt.Sprintf("thread-%d", len(threads)),
-			"firstIndex":  firstPrompt["index"],
-			"promptCount": len(threadPrompts),
-			"prompts":     threadPrompts,
-			"summary":     summary,
-			"startTime":   firstPrompt["timestamp"],
-			"endTime":     lastPrompt["timestamp"],
-		}
-
-		threads = append(threads, currentThread)
+		threads = append(threads, newThread(len(threads), threadPrompts))
 	}
 
 	// Reverse to show newest first
@@ -137,50 +176,77 @@ func (h *Handler) GetPromptsAPIHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, threads)
 }
 
-// GetResponseAPIHandler returns the assistant response for a specific prompt
+// newThread builds a thread summary from consecutive prompts
+func newThread(index int, prompts []map[string]interface{}) map[string]interface{} {
+	firstPrompt := prompts[0]
+	lastPrompt := prompts[len(prompts)-1]
+
+	summary := services.Truncate(firstPrompt["content"].(string), 80)
+
+	return map[string]interface{}{
+		"id":          fmt.Sprintf("thread-%d", index),
+		"firstUuid":   firstPrompt["uuid"],
+		"promptCount": len(prompts),
+		"prompts":     prompts,
+		"summary":     summary,
+		"startTime":   firstPrompt["timestamp"],
+		"endTime":     lastPrompt["timestamp"],
+	}
+}
+
+// GetResponseAPIHandler returns the prompt identified by its UUID and the full
+// assistant turn that answered it
 func (h *Handler) GetResponseAPIHandler(c echo.Context) error {
 	encodedPath := c.Param("encodedPath")
 	sessionID := c.Param("sessionId")
-	promptIndex := c.Param("promptIndex")
+	promptUUID := c.Param("promptUuid")
 
-	session, err := h.sessionService.GetSession(encodedPath, sessionID)
+	turn, err := h.sessions.GetTurn(encodedPath, sessionID, promptUUID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return apiError(c, err)
 	}
 
-	// Find the prompt and its response
-	var promptMsg, responseMsg *map[string]interface{}
-
-	for i, msg := range session.Messages {
-		if msg.Role == "user" {
-			// Convert index to string and compare
-			currentIdx := 0
-			for j := 0; j <= i; j++ {
-				if session.Messages[j].Role == "user" {
-					currentIdx++
-				}
-			}
+	promptMsg := map[string]interface{}{
+		"uuid":      turn.Prompt.UUID,
+		"kind":      turn.Prompt.Kind,
+		"command":   turn.Prompt.Command,
+		"content":   strings.TrimSpace(services.PromptText(turn.Prompt)),
+		"timestamp": turn.Prompt.Timestamp,
+		"images":    turn.Prompt.Images,
+	}
 
-			if promptIndex == fmt.Sprintf("%d", i) {
-				promptMsg = &map[string]interface{}{
-					"uuid":      msg.UUID,
-					"content":   msg.Content,
-					"timestamp": msg.Timestamp,
-				}
+	var responseMsg map[string]interface{}
+	if len(turn.Messages) > 0 {
+		// Tool calls on the turn carry their results; attach those to each step
+		callsByID := make(map[string]models.ToolCall)
+		for _, call := range turn.ToolCalls {
+			callsByID[call.ID] = call
+		}
 
-				// Find the next assistant message
-				for j := i + 1; j < len(session.Messages); j++ {
-					if session.Messages[j].Role == "assistant" {
-						responseMsg = &map[string]interface{}{
-							"uuid":      session.Messages[j].UUID,
-							"content":   session.Messages[j].Content,
-							"timestamp": session.Messages[j].Timestamp,
-						}
-						break
-					}
-				}
-				break
+		var steps []map[string]interface{}
+		for _, msg := range turn.Messages {
+			if msg.Role != "assistant" {
+				continue
 			}
+			var calls []models.ToolCall
+			for _, call := range msg.ToolCalls {
+				calls = append(calls, callsByID[call.ID])
+			}
+			steps = append(steps, map[string]interface{}{
+				"uuid":      msg.UUID,
+				"content":   msg.Content,
+				"toolCalls": calls,
+				"timestamp": msg.Timestamp,
+			})
+		}
+
+		responseMsg = map[string]interface{}{
+			"uuid":      turn.Messages[0].UUID,
+			"content":   turn.Response,
+			"timestamp": turn.Messages[0].Timestamp,
+			"endTime":   turn.EndTime,
+			"toolCalls": turn.ToolCalls,
+			"steps":     steps,
 		}
 	}
 
@@ -195,31 +261,96 @@ func (h *Handler) GetSessionFullAPIHandler(c echo.Context) error {
 	encodedPath := c.Param("encodedPath")
 	sessionID := c.Param("sessionId")
 
-	session, err := h.sessionService.GetSession(encodedPath, sessionID)
+	session, err := h.sessions.GetSession(encodedPath, sessionID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	// Transform messages for frontend
 	var chatMessages []map[string]interface{}
+	var leading []string
 	for i, msg := range session.Messages {
 		content := strings.TrimSpace(msg.Content)
-		if content == "" {
+		images := messageImages(msg)
+		if content == "" && !hasDiff(msg.ToolCalls) && len(images) == 0 {
+			// Links to messages left out, such as tool calls that only read
+			// files, focus the message shown before them
+			if msg.UUID != "" {
+				if n := len(chatMessages); n > 0 {
+					chatMessages[n-1]["anchors"] = append(chatMessages[n-1]["anchors"].([]string), msg.UUID)
+				} else {
+					leading = append(leading, msg.UUID)
+				}
+			}
 			continue
 		}
 
 		chatMessages = append(chatMessages, map[string]interface{}{
 			"index":     i,
 			"uuid":      msg.UUID,
-			"role":      msg.Role, 
+			"role":      msg.Role,
+			"kind":      msg.Kind,
+			"command":   msg.Command,
+			"summary":   msg.Summary,
 			"content":   msg.Content,
 			"timestamp": msg.Timestamp,
+			"toolCalls": msg.ToolCalls,
+			"images":    images,
+			"anchors":   []string{},
 		})
 	}
+	if len(chatMessages) > 0 {
+		chatMessages[0]["anchors"] = append(leading, chatMessages[0]["anchors"].([]string)...)
+	}
 
 	return c.JSON(http.StatusOK, chatMessages)
 }
 
+// GetSessionMetadataAPIHandler returns the Claude Code versions, working
+// directories, models, branches, duration and token usage of a session
+func (h *Handler) GetSessionMetadataAPIHandler(c echo.Context) error {
+	session, err := h.sessions.GetSession(c.Param("encodedPath"), c.Param("sessionId"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, session.Metadata)
+}
+
+// GetSessionTodosAPIHandler returns how the agent's todo list evolved in a
+// session
+func (h *Handler) GetSessionTodosAPIHandler(c echo.Context) error {
+	timeline, err := h.sessions.GetSessionTodos(c.Param("encodedPath"), c.Param("sessionId"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, timeline)
+}
+
+// messageImages returns the images of a message and of the tool results in it
+func messageImages(msg models.ConversationMessage) []models.ImageRef {
+	images := msg.Images
+	for _, result := range msg.ToolResults {
+		images = append(images, result.Images...)
+	}
+	return images
+}
+
+// hasDiff reports whether any of the tool calls changed a file
+func hasDiff(calls []models.ToolCall) bool {
+	for _, call := range calls {
+		if call.Diff != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // SearchHandler handles search requests
 func (h *Handler) SearchHandler(c echo.Context) error {
 	query := c.QueryParam("q")
@@ -228,7 +359,7 @@ func (h *Handler) SearchHandler(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/")
 	}
 
-	sessions, err := h.sessionService.SearchSessions(query)
+	sessions, err := h.sessions.SearchSessions(query)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Search failed: "+err.Error())
 	}
@@ -255,14 +386,14 @@ func getProjectName(path string) string {
 // ArchiveSessionHandler toggles archive status
 func (h *Handler) ArchiveSessionHandler(c echo.Context) error {
 	sessionID := c.Param("sessionId")
-	
-	isArchived, err := h.sessionService.ToggleArchiveSession(sessionID)
+
+	isArchived, err := h.archive.ToggleArchiveSession(sessionID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
+		"success":  true,
 		"archived": isArchived,
 	})
 }
@@ -270,14 +401,88 @@ func (h *Handler) ArchiveSessionHandler(c echo.Context) error {
 // ArchiveProjectHandler toggles archive status for a project
 func (h *Handler) ArchiveProjectHandler(c echo.Context) error {
 	encodedPath := c.Param("encodedPath")
-	
-	isArchived, err := h.sessionService.ToggleArchiveProject(encodedPath)
+
+	isArchived, err := h.archive.ToggleArchiveProject(encodedPath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
+		"success":  true,
 		"archived": isArchived,
 	})
 }
+
+// CommandsHandler shows slash command usage statistics
+func (h *Handler) CommandsHandler(c echo.Context) error {
+	usage, err := h.commands.GetCommandUsage()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load commands: "+err.Error())
+	}
+
+	return c.Render(http.StatusOK, "commands.html", map[string]interface{}{
+		"Commands": usage,
+	})
+}
+
+// GetCommandUsageAPIHandler returns slash command usage statistics as JSON
+func (h *Handler) GetCommandUsageAPIHandler(c echo.Context) error {
+	usage, err := h.commands.GetCommandUsage()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+// commandRequest is the body of the command preview and create endpoints
+type commandRequest struct {
+	models.CommandDraft
+	Overwrite bool `json:"overwrite"`
+}
+
+// PreviewCommandAPIHandler renders a prompt as a custom slash command file
+// without writing it, reporting whether it would replace an existing one
+func (h *Handler) PreviewCommandAPIHandler(c echo.Context) error {
+	var req commandRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	file, err := h.commands.RenderCommandFile(req.CommandDraft)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, file)
+}
+
+// CreateCommandAPIHandler writes a prompt as a custom slash command file
+func (h *Handler) CreateCommandAPIHandler(c echo.Context) error {
+	var req commandRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	file, err := h.commands.SaveCommandFile(req.CommandDraft, req.Overwrite)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusCreated, file)
+}
+
+// apiError writes err as a JSON error with a status matching its cause
+func apiError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrReadOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrNotRepo):
+		status = http.StatusUnprocessableEntity
+	}
+	return c.JSON(status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DataHealthHandler shows what the viewer could not read in the session files
+func (h *Handler) DataHealthHandler(c echo.Context) error {
+	health, err := h.stats.GetDataHealth()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to check data: "+err.Error())
+	}
+
+	return c.Render(http.StatusOK, "health.html", map[string]interface{}{
+		"Health": health,
+	})
+}
+
+// GetDataHealthAPIHandler returns the unreadable files, skipped lines and
+// unknown line and content block types of every session file
+func (h *Handler) GetDataHealthAPIHandler(c echo.Context) error {
+	health, err := h.stats.GetDataHealth()
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, health)
+}
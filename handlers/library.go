@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// LibraryHandler shows the prompt library
+func (h *Handler) LibraryHandler(c echo.Context) error {
+	filter := libraryFilter(c)
+	items, err := h.library.ListLibrary(filter)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load library: "+err.Error())
+	}
+
+	tagCounts, err := h.library.LibraryTags()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load library: "+err.Error())
+	}
+	var tags []string
+	for tag := range tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return c.Render(http.StatusOK, "library.html", map[string]interface{}{
+		"Items":     items,
+		"Tags":      tags,
+		"TagCounts": tagCounts,
+		"Filter":    filter,
+	})
+}
+
+// ListLibraryAPIHandler returns saved prompts as JSON, filtered by the
+// tag, starred and q query parameters
+func (h *Handler) ListLibraryAPIHandler(c echo.Context) error {
+	items, err := h.library.ListLibrary(libraryFilter(c))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// GetLibraryItemAPIHandler returns a single saved prompt
+func (h *Handler) GetLibraryItemAPIHandler(c echo.Context) error {
+	item, err := h.library.GetLibraryItem(c.Param("id"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// CreateLibraryItemAPIHandler saves a prompt to the library
+func (h *Handler) CreateLibraryItemAPIHandler(c echo.Context) error {
+	var item models.LibraryItem
+	if err := c.Bind(&item); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	item, err := h.library.AddToLibrary(item)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusCreated, item)
+}
+
+// UpdateLibraryItemAPIHandler changes the title, notes, tags or star of a saved prompt
+func (h *Handler) UpdateLibraryItemAPIHandler(c echo.Context) error {
+	var update models.LibraryUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	item, err := h.library.UpdateLibraryItem(c.Param("id"), update)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// DeleteLibraryItemAPIHandler removes a prompt from the library
+func (h *Handler) DeleteLibraryItemAPIHandler(c echo.Context) error {
+	if err := h.library.DeleteLibraryItem(c.Param("id")); err != nil {
+		return apiError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func libraryFilter(c echo.Context) models.LibraryFilter {
+	return models.LibraryFilter{
+		Tag:         c.QueryParam("tag"),
+		Query:       c.QueryParam("q"),
+		StarredOnly: c.QueryParam("starred") == "true",
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// retentionRequest is the body of the retention endpoints
+type retentionRequest struct {
+	Rules []models.RetentionRule `json:"rules"`
+}
+
+// StartRetentionJob applies the retention rules in the background every interval
+func (h *Handler) StartRetentionJob(ctx context.Context, interval time.Duration) {
+	h.archive.StartRetentionJob(ctx, interval)
+}
+
+// GetRetentionAPIHandler returns the retention rules and their last run
+func (h *Handler) GetRetentionAPIHandler(c echo.Context) error {
+	data, err := h.archive.GetRetention()
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, data)
+}
+
+// UpdateRetentionAPIHandler replaces the retention rules
+func (h *Handler) UpdateRetentionAPIHandler(c echo.Context) error {
+	var req retentionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rules, err := h.archive.SaveRetentionRules(req.Rules)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"rules": rules,
+	})
+}
+
+// PreviewRetentionAPIHandler returns the sessions the rules in the body, or
+// the stored rules when none are given, would archive
+func (h *Handler) PreviewRetentionAPIHandler(c echo.Context) error {
+	var req retentionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	matches, err := h.archive.PreviewRetention(req.Rules)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count":   len(matches),
+		"matches": matches,
+	})
+}
+
+// RunRetentionAPIHandler applies the stored retention rules now
+func (h *Handler) RunRetentionAPIHandler(c echo.Context) error {
+	run, err := h.archive.ApplyRetention()
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, run)
+}
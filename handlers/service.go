@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+	"github.com/yugo-ibuki/claude-code-prompt-share/services"
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+)
+
+// ProjectService reads projects and sessions, and the titles and tags given
+// to sessions
+type ProjectService interface {
+	GetAllProjects() ([]models.Project, error)
+	GetProjectSessionsInfo(encodedPath string) ([]models.SessionInfo, error)
+	GetSession(encodedPath, sessionID string) (models.Session, error)
+	GetTurns(encodedPath, sessionID string) ([]models.Turn, error)
+	GetTurn(encodedPath, sessionID, promptUUID string) (models.Turn, error)
+	GetSessionTodos(encodedPath, sessionID string) (models.TodoTimeline, error)
+	GetSessionChangeset(encodedPath, sessionID string) (models.Changeset, error)
+	GetSessionCommits(encodedPath, sessionID string) ([]models.Commit, error)
+	GetProjectCommits(encodedPath string) ([]models.Commit, error)
+	GetProjectFiles(encodedPath, query string) ([]models.FileActivity, error)
+	SearchSessions(query string) ([]models.SessionInfo, error)
+	OpenBlob(hash string, thumbnail bool) (string, string, error)
+	SetSessionAnnotation(sessionID string, title *string, tags *[]string) (models.SessionAnnotation, error)
+	SessionTags() map[string]int
+	ReadOnly() bool
+}
+
+// ArchiveService archives sessions and projects, by hand or by retention rules
+type ArchiveService interface {
+	ToggleArchiveSession(sessionID string) (bool, error)
+	ToggleArchiveProject(encodedPath string) (bool, error)
+	GetArchived() (models.ArchiveListing, error)
+	SelectSessions(selection models.ArchiveSelection, archived bool) ([]models.SessionInfo, error)
+	SetSessionsArchived(sessionIDs []string, archived bool) ([]string, error)
+	SetProjectArchived(encodedPath string, archived bool) (bool, error)
+	GetRetention() (models.RetentionData, error)
+	SaveRetentionRules(rules []models.RetentionRule) ([]models.RetentionRule, error)
+	PreviewRetention(rules []models.RetentionRule) ([]models.RetentionMatch, error)
+	ApplyRetention() (models.RetentionRun, error)
+	StartRetentionJob(ctx context.Context, interval time.Duration)
+}
+
+// TrashService deletes sessions into the trash and restores them
+type TrashService interface {
+	ListTrash() ([]models.TrashItem, error)
+	TrashSession(encodedPath, sessionID string) (models.TrashItem, error)
+	RestoreTrash(id string) (models.TrashItem, error)
+	PurgeTrash(id string) error
+	ReadOnly() bool
+}
+
+// LibraryService keeps the prompt library
+type LibraryService interface {
+	ListLibrary(filter models.LibraryFilter) ([]models.LibraryItem, error)
+	GetLibraryItem(id string) (models.LibraryItem, error)
+	AddToLibrary(item models.LibraryItem) (models.LibraryItem, error)
+	UpdateLibraryItem(id string, update models.LibraryUpdate) (models.LibraryItem, error)
+	DeleteLibraryItem(id string) error
+	LibraryTags() (map[string]int, error)
+}
+
+// CommandService reports slash command usage and writes custom commands
+type CommandService interface {
+	GetCommandUsage() ([]models.CommandUsage, error)
+	RenderCommandFile(draft models.CommandDraft) (models.CommandFile, error)
+	SaveCommandFile(draft models.CommandDraft, overwrite bool) (models.CommandFile, error)
+}
+
+// StatsService reports usage statistics and the health of the session data
+type StatsService interface {
+	GetStats(since time.Time) (models.Stats, error)
+	GetToolStats(since time.Time, encodedPath string) (models.ToolStats, error)
+	GetDataHealth() (models.DataHealth, error)
+}
+
+// SessionService is everything the handlers need from the session service.
+// *services.SessionService implements it; other implementations can be
+// passed with WithSessionService.
+type SessionService interface {
+	ProjectService
+	ArchiveService
+	TrashService
+	LibraryService
+	CommandService
+	StatsService
+}
+
+// Option configures the handlers
+type Option func(*options)
+
+type options struct {
+	service  SessionService
+	source   sources.SessionSource
+	dataDir  string
+	logger   *log.Logger
+	readOnly bool
+}
+
+// WithSessionService serves sessions from service. The other options
+// configure the service NewHandler creates otherwise, and are then ignored.
+func WithSessionService(service SessionService) Option {
+	return func(o *options) {
+		o.service = service
+	}
+}
+
+// WithSource reads sessions from source instead of ~/.claude
+func WithSource(source sources.SessionSource) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithDataDir keeps the viewer's own state in dir instead of data/ in the
+// working directory
+func WithDataDir(dir string) Option {
+	return func(o *options) {
+		o.dataDir = dir
+	}
+}
+
+// WithLogger logs problems the viewer works around to logger instead of the
+// standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithReadOnly stops the handlers from modifying anything under ~/.claude
+func WithReadOnly(readOnly bool) Option {
+	return func(o *options) {
+		o.readOnly = readOnly
+	}
+}
+
+// newSessionService creates the session service the options describe. It
+// fails when there is no source and the home directory is unknown, or when
+// the source has no readable projects folder.
+func (o *options) newSessionService() (*services.SessionService, error) {
+	source := o.source
+	if source == nil {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot locate ~/.claude: %w", err)
+		}
+		source = sources.NewDir(filepath.Join(homeDir, ".claude"))
+	}
+	if _, err := source.Projects(); err != nil {
+		if local, ok := source.(sources.Local); ok {
+			return nil, fmt.Errorf("cannot read Claude Code projects in %s: %w", local.Root(), err)
+		}
+		return nil, fmt.Errorf("cannot read Claude Code projects: %w", err)
+	}
+
+	opts := []services.Option{services.WithReadOnly(o.readOnly)}
+	if o.dataDir != "" {
+		opts = append(opts, services.WithDataDir(o.dataDir))
+	}
+	if o.logger != nil {
+		opts = append(opts, services.WithLogger(o.logger))
+	}
+	return services.NewSessionService(source, opts...), nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StatsHandler shows the statistics dashboard
+func (h *Handler) StatsHandler(c echo.Context) error {
+	return c.Render(http.StatusOK, "stats.html", map[string]interface{}{
+		"Days": c.QueryParam("days"),
+	})
+}
+
+// GetStatsAPIHandler returns activity statistics as JSON, limited to the
+// last n days with the days query parameter
+func (h *Handler) GetStatsAPIHandler(c echo.Context) error {
+	since, err := sinceDays(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	stats, err := h.stats.GetStats(since)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// sinceDays returns the start of the period given by the days query
+// parameter, or the zero time for all time
+func sinceDays(c echo.Context) (time.Time, error) {
+	value := c.QueryParam("days")
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return time.Time{}, errors.New("days must be a positive number")
+	}
+	if days == 0 {
+		return time.Time{}, nil
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return today.AddDate(0, 0, 1-days), nil
+}
+
+// ToolStatsHandler shows tool usage analytics with the sessions behind them
+func (h *Handler) ToolStatsHandler(c echo.Context) error {
+	projects, err := h.sessions.GetAllProjects()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load projects: "+err.Error())
+	}
+
+	names := make(map[string]string)
+	for _, project := range projects {
+		names[project.EncodedPath] = getProjectName(project.DecodedPath)
+	}
+
+	return c.Render(http.StatusOK, "tools.html", map[string]interface{}{
+		"Days":         c.QueryParam("days"),
+		"Project":      c.QueryParam("project"),
+		"Projects":     projects,
+		"ProjectNames": names,
+	})
+}
+
+// GetToolStatsAPIHandler returns tool usage statistics as JSON, limited to
+// the last n days with the days query parameter and to a project with the
+// project query parameter
+func (h *Handler) GetToolStatsAPIHandler(c echo.Context) error {
+	since, err := sinceDays(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	stats, err := h.stats.GetToolStats(since, c.QueryParam("project"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrashHandler shows the deleted sessions
+func (h *Handler) TrashHandler(c echo.Context) error {
+	items, err := h.trash.ListTrash()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load trash: "+err.Error())
+	}
+
+	return c.Render(http.StatusOK, "trash.html", map[string]interface{}{
+		"Items":    items,
+		"ReadOnly": h.trash.ReadOnly(),
+	})
+}
+
+// ListTrashAPIHandler returns the deleted sessions as JSON
+func (h *Handler) ListTrashAPIHandler(c echo.Context) error {
+	items, err := h.trash.ListTrash()
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// DeleteSessionAPIHandler moves a session to the trash. The confirm query
+// parameter must repeat the session ID.
+func (h *Handler) DeleteSessionAPIHandler(c echo.Context) error {
+	sessionID := c.Param("sessionId")
+	if c.QueryParam("confirm") != sessionID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "confirm must be set to the session ID"})
+	}
+
+	item, err := h.trash.TrashSession(c.Param("encodedPath"), sessionID)
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// RestoreTrashAPIHandler moves a deleted session back to its project
+func (h *Handler) RestoreTrashAPIHandler(c echo.Context) error {
+	item, err := h.trash.RestoreTrash(c.Param("id"))
+	if err != nil {
+		return apiError(c, err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// PurgeTrashAPIHandler permanently deletes a session from the trash. The
+// confirm query parameter must repeat the trash item ID.
+func (h *Handler) PurgeTrashAPIHandler(c echo.Context) error {
+	id := c.Param("id")
+	if c.QueryParam("confirm") != id {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "confirm must be set to the trash item ID"})
+	}
+
+	if err := h.trash.PurgeTrash(id); err != nil {
+		return apiError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
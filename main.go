@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"html/template"
 	"io"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/yugo-ibuki/claude-code-prompt-share/handlers"
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
 )
 
+// retentionInterval is how often the retention rules are applied
+const retentionInterval = time.Hour
+
 type TemplateRenderer struct {
 	templates *template.Template
 }
@@ -19,6 +27,24 @@ func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c
 }
 
 func main() {
+	readOnly := flag.Bool("read-only", false, "never modify files under ~/.claude (disables deleting sessions and saving commands)")
+	sourcePath := flag.String("source", "", "the .claude directory, or a ZIP backup of one, to read sessions from (default ~/.claude)")
+	flag.Parse()
+
+	opts := []handlers.Option{handlers.WithReadOnly(*readOnly)}
+	if *sourcePath != "" {
+		source, err := openSource(*sourcePath)
+		if err != nil {
+			log.Fatalf("Failed to open sessions: %v", err)
+		}
+		opts = append(opts, handlers.WithSource(source))
+	}
+	// Initialize handlers
+	h, err := handlers.NewHandler(opts...)
+	if err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+
 	e := echo.New()
 
 	// Middleware
@@ -42,31 +68,88 @@ func main() {
 	}
 
 	// Template renderer
+	templates, err := template.New("").Funcs(funcMap).ParseGlob("templates/*.html")
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
 	renderer := &TemplateRenderer{
-		templates: template.Must(template.New("").Funcs(funcMap).ParseGlob("templates/*.html")),
+		templates: templates,
 	}
 	e.Renderer = renderer
 
 	// Static files
 	e.Static("/static", "static")
 
-	// Initialize handlers
-	h := handlers.NewHandler()
-
 	// Routes
 	e.GET("/", h.IndexHandler)
 	e.GET("/search", h.SearchHandler)
+	e.GET("/commands", h.CommandsHandler)
+	e.GET("/library", h.LibraryHandler)
+	e.GET("/archive", h.ArchiveHandler)
+	e.GET("/trash", h.TrashHandler)
+	e.GET("/stats", h.StatsHandler)
+	e.GET("/stats/tools", h.ToolStatsHandler)
+	e.GET("/files", h.FilesHandler)
+	e.GET("/commits", h.CommitsHandler)
+	e.GET("/health", h.DataHealthHandler)
 
 	// API Routes
 	e.GET("/api/projects", h.GetProjectsAPIHandler)
 	e.GET("/api/projects/:encodedPath/sessions", h.GetSessionsAPIHandler)
 	e.GET("/api/projects/:encodedPath/sessions/:sessionId/prompts", h.GetPromptsAPIHandler)
-	e.GET("/api/projects/:encodedPath/sessions/:sessionId/prompts/:promptIndex", h.GetResponseAPIHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/prompts/:promptUuid", h.GetResponseAPIHandler)
 	e.GET("/api/projects/:encodedPath/sessions/:sessionId/full", h.GetSessionFullAPIHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/metadata", h.GetSessionMetadataAPIHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/todos", h.GetSessionTodosAPIHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/changes", h.GetSessionChangesAPIHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/patch", h.GetSessionPatchHandler)
+	e.GET("/api/projects/:encodedPath/files", h.GetProjectFilesAPIHandler)
+	e.GET("/api/projects/:encodedPath/commits", h.GetProjectCommitsAPIHandler)
+	e.GET("/api/blobs/:hash", h.GetBlobHandler)
+	e.GET("/api/projects/:encodedPath/sessions/:sessionId/commits", h.GetSessionCommitsAPIHandler)
+	e.GET("/api/commands/usage", h.GetCommandUsageAPIHandler)
+	e.POST("/api/commands/preview", h.PreviewCommandAPIHandler)
+	e.POST("/api/commands", h.CreateCommandAPIHandler)
+	e.GET("/api/library", h.ListLibraryAPIHandler)
+	e.POST("/api/library", h.CreateLibraryItemAPIHandler)
+	e.GET("/api/library/:id", h.GetLibraryItemAPIHandler)
+	e.PATCH("/api/library/:id", h.UpdateLibraryItemAPIHandler)
+	e.DELETE("/api/library/:id", h.DeleteLibraryItemAPIHandler)
+	e.GET("/api/sessions/tags", h.GetSessionTagsAPIHandler)
+	e.PATCH("/api/sessions/:sessionId", h.UpdateSessionAnnotationHandler)
 	e.POST("/api/sessions/:sessionId/archive", h.ArchiveSessionHandler)
 	e.POST("/api/projects/:encodedPath/archive", h.ArchiveProjectHandler)
+	e.GET("/api/archive", h.GetArchiveAPIHandler)
+	e.POST("/api/archive/bulk", h.BulkArchiveAPIHandler)
+	e.PUT("/api/archive/sessions/:sessionId", h.ArchiveSessionAPIHandler)
+	e.DELETE("/api/archive/sessions/:sessionId", h.ArchiveSessionAPIHandler)
+	e.PUT("/api/archive/projects/:encodedPath", h.ArchiveProjectAPIHandler)
+	e.DELETE("/api/archive/projects/:encodedPath", h.ArchiveProjectAPIHandler)
+	e.DELETE("/api/projects/:encodedPath/sessions/:sessionId", h.DeleteSessionAPIHandler)
+	e.GET("/api/trash", h.ListTrashAPIHandler)
+	e.POST("/api/trash/:id/restore", h.RestoreTrashAPIHandler)
+	e.DELETE("/api/trash/:id", h.PurgeTrashAPIHandler)
+	e.GET("/api/stats", h.GetStatsAPIHandler)
+	e.GET("/api/stats/tools", h.GetToolStatsAPIHandler)
+	e.GET("/api/health/data", h.GetDataHealthAPIHandler)
+	e.GET("/api/retention", h.GetRetentionAPIHandler)
+	e.PUT("/api/retention", h.UpdateRetentionAPIHandler)
+	e.POST("/api/retention/preview", h.PreviewRetentionAPIHandler)
+	e.POST("/api/retention/run", h.RunRetentionAPIHandler)
+
+	// Apply retention rules in the background
+	h.StartRetentionJob(context.Background(), retentionInterval)
 
 	// Start server
 	log.Println("Starting Claude Code Session Viewer on http://localhost:8080")
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// openSource returns the sessions at path: a ZIP backup when it ends in .zip,
+// a .claude directory otherwise
+func openSource(path string) (sources.SessionSource, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return sources.OpenZip(path)
+	}
+	return sources.NewDir(path), nil
+}
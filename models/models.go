@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // JSONLMessage represents a single line in the JSONL file
 type JSONLMessage struct {
@@ -16,19 +19,24 @@ type JSONLMessage struct {
 	UUID             string                 `json:"uuid,omitempty"`
 	Timestamp        time.Time              `json:"timestamp,omitempty"`
 	ThinkingMetadata map[string]interface{} `json:"thinkingMetadata,omitempty"`
-	Todos            []interface{}          `json:"todos,omitempty"`
+	Todos            json.RawMessage        `json:"todos,omitempty"` // todo list when the line was written
 	RequestID        string                 `json:"requestId,omitempty"`
+	IsMeta           bool                   `json:"isMeta,omitempty"`
+	IsCompactSummary bool                   `json:"isCompactSummary,omitempty"`
+	Summary          string                 `json:"summary,omitempty"`  // set on "summary" lines
+	LeafUUID         string                 `json:"leafUuid,omitempty"` // set on "summary" lines
+	ToolUseResult    json.RawMessage        `json:"toolUseResult,omitempty"`
 }
 
 // MessageContent represents the actual message content
 type MessageContent struct {
-	Role       string                   `json:"role"`
-	Content    interface{}              `json:"content"` // Can be string or array
-	Model      string                   `json:"model,omitempty"`
-	ID         string                   `json:"id,omitempty"`
-	Type       string                   `json:"type,omitempty"`
-	StopReason *string                  `json:"stop_reason,omitempty"`
-	Usage      map[string]interface{}   `json:"usage,omitempty"`
+	Role       string                 `json:"role"`
+	Content    interface{}            `json:"content"` // Can be string or array
+	Model      string                 `json:"model,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	StopReason *string                `json:"stop_reason,omitempty"`
+	Usage      map[string]interface{} `json:"usage,omitempty"`
 }
 
 // Session represents a conversation session
@@ -37,17 +45,291 @@ type Session struct {
 	ProjectPath string
 	ProjectName string
 	Messages    []ConversationMessage
+	Summary     string           // summary of the latest branch, used as the title
+	Summaries   []SessionSummary // summaries of every branch, in message order
 	StartTime   time.Time
 	EndTime     time.Time
+	GitBranch   string // git branch the session ended on
+	Metadata    SessionMetadata
+	Todos       []TodoSnapshot // the agent's todo list each time it changed
+	Diagnostics ParseDiagnostics
+}
+
+// ParseDiagnostics is what the parser skipped or did not recognize in a
+// session file
+type ParseDiagnostics struct {
+	SkippedLines  int           `json:"skippedLines"`  // lines that could not be parsed
+	LineErrors    []LineError   `json:"lineErrors"`    // the first of them
+	UnknownTypes  []UnknownType `json:"unknownTypes"`  // line types
+	UnknownBlocks []UnknownType `json:"unknownBlocks"` // message content block types
+}
+
+// Empty reports whether the parser understood the whole file
+func (d ParseDiagnostics) Empty() bool {
+	return d.SkippedLines == 0 && len(d.UnknownTypes) == 0 && len(d.UnknownBlocks) == 0
+}
+
+// LineError is a line of a session file that could not be parsed
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// UnknownType is a line or content block type the parser does not know
+type UnknownType struct {
+	Type      string `json:"type"`
+	Count     int    `json:"count"`
+	FirstLine int    `json:"firstLine"`
+}
+
+// DataHealth reports what the viewer could not read in ~/.claude/projects and
+// in its own data directory
+type DataHealth struct {
+	GeneratedAt   time.Time          `json:"generatedAt"`
+	Projects      int                `json:"projects"`
+	SessionFiles  int                `json:"sessionFiles"`
+	FailedFiles   int                `json:"failedFiles"` // files or directories that could not be read at all
+	SkippedLines  int                `json:"skippedLines"`
+	UnknownTypes  []UnknownTypeTotal `json:"unknownTypes"`
+	UnknownBlocks []UnknownTypeTotal `json:"unknownBlocks"`
+	Files         []FileHealth       `json:"files"`        // files with problems
+	CorruptFiles  []string           `json:"corruptFiles"` // viewer state files moved aside as unreadable
+}
+
+// UnknownTypeTotal is an unknown type counted over every session file
+type UnknownTypeTotal struct {
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Files   int    `json:"files"`
+	Example string `json:"example"` // file and line of its first occurrence
+}
+
+// FileHealth is a session file or project directory the viewer had trouble
+// reading
+type FileHealth struct {
+	Path        string            `json:"path"` // relative to ~/.claude/projects
+	EncodedPath string            `json:"encodedPath"`
+	SessionID   string            `json:"sessionId,omitempty"` // empty for directories and agent transcripts
+	Error       string            `json:"error,omitempty"`     // why it could not be read at all
+	Diagnostics *ParseDiagnostics `json:"diagnostics,omitempty"`
+}
+
+// TodoItem is an item of the agent's todo list, as written by TodoWrite
+type TodoItem struct {
+	Content    string `json:"content"`
+	Status     string `json:"status"` // "pending", "in_progress" or "completed"
+	ActiveForm string `json:"activeForm,omitempty"`
+}
+
+// TodoSnapshot is the todo list after the agent changed it
+type TodoSnapshot struct {
+	MessageUUID string       `json:"messageUuid"`
+	PromptUUID  string       `json:"promptUuid,omitempty"` // prompt being worked on
+	Timestamp   time.Time    `json:"timestamp"`
+	Todos       []TodoItem   `json:"todos"`
+	Changes     []TodoChange `json:"changes"`
+}
+
+// TodoChange is an item added to, removed from or updated in the todo list
+type TodoChange struct {
+	Content string `json:"content"`
+	From    string `json:"from,omitempty"` // empty when added
+	To      string `json:"to"`             // "removed" when dropped
+}
+
+// TodoTimeline is how a session's todo list evolved
+type TodoTimeline struct {
+	Snapshots []TodoSnapshot `json:"snapshots"`
+	Planned   int            `json:"planned"`   // distinct items ever on the list
+	Completed int            `json:"completed"` // distinct items completed
+}
+
+// SessionMetadata describes the environment a session ran in. Lists are in
+// order of first appearance.
+type SessionMetadata struct {
+	Versions        []string   `json:"versions"` // Claude Code versions
+	Cwds            []string   `json:"cwds"`     // working directories
+	UserTypes       []string   `json:"userTypes"`
+	Models          []string   `json:"models"`
+	GitBranches     []string   `json:"gitBranches"`
+	DurationSeconds int64      `json:"durationSeconds"`
+	Responses       int        `json:"responses"` // API responses, for the token counts
+	Tokens          TokenUsage `json:"tokens"`
+}
+
+// TokenUsage totals the tokens of API responses
+type TokenUsage struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheCreation int64 `json:"cacheCreation"`
+	CacheRead     int64 `json:"cacheRead"`
+	Total         int64 `json:"total"`
+}
+
+// MessageKind classifies what a conversation message actually is. Claude Code
+// records tool results and injected content with the "user" role, so the role
+// alone does not tell what a person typed.
+type MessageKind string
+
+const (
+	KindPrompt        MessageKind = "prompt"         // text typed by a person
+	KindSlashCommand  MessageKind = "slash_command"  // a /command invocation
+	KindToolResult    MessageKind = "tool_result"    // output of a tool call
+	KindCommandOutput MessageKind = "command_output" // local command or bash mode output
+	KindHook          MessageKind = "hook"           // hook feedback
+	KindInterrupted   MessageKind = "interrupted"    // "[Request interrupted by user]"
+	KindMeta          MessageKind = "meta"           // system-injected content
+	KindAssistant     MessageKind = "assistant"
+)
+
+// IsHuman reports whether the kind is something a person typed
+func (k MessageKind) IsHuman() bool {
+	return k == KindPrompt || k == KindSlashCommand
 }
 
 // ConversationMessage represents a user or assistant message
 type ConversationMessage struct {
-	UUID      string
-	Role      string // "user" or "assistant"
+	UUID        string
+	ParentUUID  string // nearest user/assistant ancestor, empty for roots
+	Role        string // "user" or "assistant"
+	Kind        MessageKind
+	Content     string
+	Timestamp   time.Time
+	Model       string // model that wrote an assistant message
+	IsAgent     bool
+	ToolCalls   []ToolCall
+	ToolResults []ToolResult
+	Command     *SlashCommand `json:",omitempty"` // set for slash command invocations
+	Summary     string        `json:",omitempty"` // summary of the branch ending at this message
+	Images      []ImageRef    `json:",omitempty"` // images pasted into the message
+}
+
+// ImageRef points to an image of a session in the blob store. The image data
+// itself is never included in messages.
+type ImageRef struct {
+	Hash      string // SHA-256 of the image, empty when omitted
+	MediaType string
+	Size      int  // bytes
+	Omitted   bool // not stored, being too large or not a PNG, JPEG, GIF or WebP image
+}
+
+// SessionSummary is a summary record Claude Code writes for the conversation
+// branch ending at LeafUUID. The record is often stored in a later session file.
+type SessionSummary struct {
+	Summary  string
+	LeafUUID string
+}
+
+// SlashCommand represents a parsed /command invocation
+type SlashCommand struct {
+	Name     string // without the leading slash, e.g. "review" or "frontend:component"
+	Args     string
+	Body     string // expanded prompt of a custom command
+	FilePath string // matching file under a commands/ folder, if any
+	Scope    string // "user" or "project" when FilePath is set
+}
+
+// CommandDraft describes a custom slash command to be written from a prompt
+type CommandDraft struct {
+	Name         string `json:"name"` // "review" or namespaced "team:review"
+	Description  string `json:"description"`
+	ArgumentHint string `json:"argumentHint"`
+	Content      string `json:"content"`
+	Scope        string `json:"scope"`       // "user" or "project"
+	EncodedPath  string `json:"encodedPath"` // project to write to when Scope is "project"
+}
+
+// CommandFile is the rendered result of a CommandDraft
+type CommandFile struct {
+	Path     string
+	Markdown string
+	Exists   bool
+}
+
+// CommandUsage aggregates how often a slash command was invoked
+type CommandUsage struct {
+	Name         string
+	FilePath     string
+	Scope        string
+	Count        int
+	SessionCount int
+	LastUsed     time.Time
+	Projects     []string
+}
+
+// ToolCall represents a tool_use content block in an assistant message
+type ToolCall struct {
+	ID     string
+	Name   string
+	Input  map[string]interface{}
+	Result *ToolResult `json:",omitempty"` // filled in when grouped into a Turn
+	Diff   *FileDiff   `json:",omitempty"` // for Edit, MultiEdit and Write
+}
+
+// FileDiff is the change a file editing tool call made, as a unified diff
+type FileDiff struct {
+	Path    string // relative to the project when inside it
+	Patch   string // unified diff with ---/+++ headers
+	Added   int
+	Removed int
+	Created bool // the file did not exist before
+	Snippet bool // only the edited text was known, so line numbers are not the file's
+}
+
+// Commit is a git commit of a project, with the sessions it was made in
+type Commit struct {
+	Hash      string          `json:"hash"`
+	ShortHash string          `json:"shortHash"`
+	Author    string          `json:"author"`
+	Time      time.Time       `json:"time"`
+	Subject   string          `json:"subject"`
+	Refs      string          `json:"refs,omitempty"`
+	Sessions  []CommitSession `json:"sessions"`
+}
+
+// CommitSession is a session a commit is attributed to
+type CommitSession struct {
+	SessionID string `json:"sessionId"`
+	Title     string `json:"title"`
+	Branch    string `json:"branch,omitempty"`
+	// Matched is "output" when the session's git commit output shows the
+	// commit, or "time" when the commit was only authored while it ran
+	Matched string `json:"matched"`
+}
+
+// Changeset is the net change a session made to the files it edited
+type Changeset struct {
+	SessionID string       `json:"sessionId"`
+	Files     []FileChange `json:"files"`
+	Patch     string       `json:"patch"` // git apply compatible patch of the applicable files
+}
+
+// FileChange is the net change a session made to one file
+type FileChange struct {
+	Path       string   `json:"path"`
+	Edits      int      `json:"edits"`
+	Diff       FileDiff `json:"diff"`
+	Applicable bool     `json:"applicable"`
+	Reason     string   `json:"reason,omitempty"` // why the change is left out of the patch
+}
+
+// ToolResult represents a tool_result content block in a user message
+type ToolResult struct {
+	ToolUseID string
 	Content   string
-	Timestamp time.Time
-	IsAgent   bool
+	IsError   bool
+	Images    []ImageRef `json:",omitempty"` // images the tool returned, such as screenshots
+}
+
+// Turn groups a human prompt with everything Claude did in response:
+// every assistant message, tool call and tool result until the next prompt
+type Turn struct {
+	Prompt    ConversationMessage
+	Messages  []ConversationMessage // assistant messages and tool results in order
+	ToolCalls []ToolCall
+	Response  string // assistant text of the whole turn
+	StartTime time.Time
+	EndTime   time.Time
 }
 
 // Project represents a Claude Code project
@@ -60,6 +342,7 @@ type Project struct {
 // SessionInfo represents basic session information for listing
 type SessionInfo struct {
 	ID                    string
+	EncodedPath           string
 	ProjectPath           string
 	ProjectName           string
 	StartTime             time.Time
@@ -68,4 +351,195 @@ type SessionInfo struct {
 	UserMessageCount      int
 	AssistantMessageCount int
 	FirstMessage          string
+	Title                 string // user-defined title, or the summary when none is set
+	Tags                  []string
+	GitBranch             string   // git branch the session ended on
+	GitBranches           []string // every git branch checked out during the session
+	SkippedLines          int      // lines that could not be parsed
+}
+
+// ArchiveListing holds the archived projects and sessions
+type ArchiveListing struct {
+	Projects []Project
+	Sessions []SessionInfo
+}
+
+// ArchiveSelection picks sessions for a bulk archive or restore. Explicit
+// session IDs take precedence over the other criteria, which are combined.
+type ArchiveSelection struct {
+	SessionIDs  []string
+	EncodedPath string
+	Before      time.Time // last activity before this time
+	Query       string    // same syntax as the search page, including #tags
+}
+
+// SessionAnnotation holds the user-editable title and tags of a session
+type SessionAnnotation struct {
+	Title     string    `json:"title,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LibraryItem is a prompt saved to the prompt library
+type LibraryItem struct {
+	ID          string    `json:"id"`
+	EncodedPath string    `json:"encodedPath"`
+	SessionID   string    `json:"sessionId"`
+	PromptUUID  string    `json:"promptUuid"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"` // prompt text at the time it was saved
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	Starred     bool      `json:"starred"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// LibraryUpdate holds the fields of a LibraryItem to change; nil fields are
+// left as they are
+type LibraryUpdate struct {
+	Title   *string   `json:"title"`
+	Notes   *string   `json:"notes"`
+	Tags    *[]string `json:"tags"`
+	Starred *bool     `json:"starred"`
+}
+
+// LibraryFilter narrows down a library listing
+type LibraryFilter struct {
+	Tag         string
+	Query       string
+	StarredOnly bool
+}
+
+// RetentionRule archives the sessions matching all of its conditions. Unset
+// conditions (zero or empty) are ignored.
+type RetentionRule struct {
+	Name             string `json:"name"`
+	Enabled          bool   `json:"enabled"`
+	OlderThanDays    int    `json:"olderThanDays,omitempty"`    // no activity for this many days
+	FewerThanPrompts int    `json:"fewerThanPrompts,omitempty"` // fewer prompts than this
+	PromptPattern    string `json:"promptPattern,omitempty"`    // regexp every prompt, and at least one, must match, e.g. ^/clear$
+}
+
+// RetentionMatch is a session selected by a retention rule
+type RetentionMatch struct {
+	Rule    string      `json:"rule"`
+	Session SessionInfo `json:"session"`
+}
+
+// RetentionRun records the result of applying the retention rules
+type RetentionRun struct {
+	Time     time.Time `json:"time"`
+	Archived int       `json:"archived"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// RetentionData holds the retention rules and the result of their last run,
+// as stored in the retention rules file
+type RetentionData struct {
+	Rules   []RetentionRule `json:"rules"`
+	LastRun *RetentionRun   `json:"lastRun,omitempty"`
+}
+
+// TrashItem is a deleted session whose files were moved to the trash
+type TrashItem struct {
+	ID          string      `json:"id"`
+	SessionID   string      `json:"sessionId"`
+	EncodedPath string      `json:"encodedPath"`
+	ProjectPath string      `json:"projectPath"`
+	Title       string      `json:"title"`
+	DeletedAt   time.Time   `json:"deletedAt"`
+	Files       []TrashFile `json:"files"`
+}
+
+// TrashFile maps a file or directory in the trash to where it came from
+type TrashFile struct {
+	Original string `json:"original"`
+	Name     string `json:"name"` // name inside the trash item's folder
+}
+
+// Stats summarizes activity across the visible projects, for the dashboard
+type Stats struct {
+	Since                *time.Time     `json:"since,omitempty"` // nil for all time
+	Projects             int            `json:"projects"`
+	Sessions             int            `json:"sessions"`
+	Prompts              int            `json:"prompts"`
+	AvgSessionMinutes    float64        `json:"avgSessionMinutes"`
+	AvgPromptsPerSession float64        `json:"avgPromptsPerSession"`
+	ByProject            []ProjectStats `json:"byProject"`
+	PromptsPerDay        []DayCount     `json:"promptsPerDay"`
+	Heatmap              [7][24]int     `json:"heatmap"` // prompts by weekday (Sunday first) and hour
+	Models               []NameCount    `json:"models"`  // assistant messages per model
+	Tools                []NameCount    `json:"tools"`   // tool calls per tool
+}
+
+// ProjectStats is the activity of a single project
+type ProjectStats struct {
+	EncodedPath  string    `json:"encodedPath"`
+	Name         string    `json:"name"`
+	Sessions     int       `json:"sessions"`
+	Prompts      int       `json:"prompts"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+// DayCount is a count for a calendar day (YYYY-MM-DD)
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// NameCount is a count for a name, such as a model or a tool
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ToolStats summarizes tool calls across sessions. Errors count tool results
+// flagged with is_error.
+type ToolStats struct {
+	Since       *time.Time  `json:"since,omitempty"` // nil for all time
+	Project     string      `json:"project,omitempty"`
+	Tools       []UsageStat `json:"tools"`       // calls per tool
+	Commands    []UsageStat `json:"commands"`    // Bash commands by program and subcommand
+	ReadFiles   []UsageStat `json:"readFiles"`   // files read, with their project
+	EditedFiles []UsageStat `json:"editedFiles"` // files edited or written, with their project
+}
+
+// UsageStat counts the uses of something and the sessions they come from
+type UsageStat struct {
+	Name     string       `json:"name"`
+	Project  string       `json:"project,omitempty"` // encoded project path
+	Count    int          `json:"count"`
+	Errors   int          `json:"errors"`
+	Sessions []SessionRef `json:"sessions"`
+}
+
+// SessionRef points at a session contributing to a statistic
+type SessionRef struct {
+	EncodedPath string    `json:"encodedPath"`
+	SessionID   string    `json:"sessionId"`
+	Title       string    `json:"title"`
+	StartTime   time.Time `json:"startTime"`
+	Count       int       `json:"count"`
+	Errors      int       `json:"errors"`
+}
+
+// FileActivity is a file read or edited by tool calls in a project's sessions
+type FileActivity struct {
+	Path        string      `json:"path"` // relative to the project when inside it
+	Reads       int         `json:"reads"`
+	Edits       int         `json:"edits"`
+	Sessions    int         `json:"sessions"`
+	LastTouched time.Time   `json:"lastTouched"`
+	Touches     []FileTouch `json:"touches"` // newest first
+}
+
+// FileTouch is a single tool call reading or editing a file
+type FileTouch struct {
+	SessionID    string    `json:"sessionId"`
+	SessionTitle string    `json:"sessionTitle"`
+	MessageUUID  string    `json:"messageUuid"` // assistant message holding the tool call
+	Tool         string    `json:"tool"`
+	Edit         bool      `json:"edit"`
+	Timestamp    time.Time `json:"timestamp"`
 }
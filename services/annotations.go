@@ -0,0 +1,99 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// SetSessionAnnotation updates the title and tags of a session. Nil values
+// are left unchanged; an empty title falls back to the Claude Code summary.
+func (s *SessionService) SetSessionAnnotation(sessionID string, title *string, tags *[]string) (models.SessionAnnotation, error) {
+	var annotation models.SessionAnnotation
+	err := s.archive.Update(func(data *ArchiveData) error {
+		if data.Sessions == nil {
+			data.Sessions = make(map[string]models.SessionAnnotation)
+		}
+
+		annotation = data.Sessions[sessionID]
+		if title != nil {
+			annotation.Title = strings.TrimSpace(*title)
+		}
+		if tags != nil {
+			annotation.Tags = normalizeTags(*tags)
+		}
+		annotation.UpdatedAt = time.Now()
+
+		if annotation.Title == "" && len(annotation.Tags) == 0 {
+			delete(data.Sessions, sessionID)
+		} else {
+			data.Sessions[sessionID] = annotation
+		}
+		return nil
+	})
+	if err != nil {
+		return models.SessionAnnotation{}, err
+	}
+	return annotation, nil
+}
+
+// SessionTags returns every session tag in use with its number of sessions
+func (s *SessionService) SessionTags() map[string]int {
+	tags := make(map[string]int)
+	for _, annotation := range s.loadSessionAnnotations() {
+		for _, tag := range annotation.Tags {
+			tags[tag]++
+		}
+	}
+	return tags
+}
+
+func (s *SessionService) loadSessionAnnotations() map[string]models.SessionAnnotation {
+	data, err := s.archive.Read()
+	if err != nil || data.Sessions == nil {
+		return map[string]models.SessionAnnotation{}
+	}
+	return data.Sessions
+}
+
+// applyAnnotation sets the user-defined title and tags on a session. The
+// title seeded from the summary is kept when the user has not set one.
+func applyAnnotation(info *models.SessionInfo, annotation models.SessionAnnotation) {
+	if annotation.Title != "" {
+		info.Title = annotation.Title
+	}
+	info.Tags = annotation.Tags
+}
+
+// splitTagQuery separates "#tag" words from the rest of a search query
+func splitTagQuery(query string) (string, []string) {
+	var words, tags []string
+	for _, word := range strings.Fields(query) {
+		if strings.HasPrefix(word, "#") && len(word) > 1 {
+			tags = append(tags, normalizeTag(word))
+		} else {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " "), tags
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Truncate shortens s to at most n characters without splitting a rune
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
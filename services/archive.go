@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// GetArchived returns the archived projects with all their sessions, and the
+// sessions archived on their own
+func (s *SessionService) GetArchived() (models.ArchiveListing, error) {
+	listing := models.ArchiveListing{
+		Projects: []models.Project{},
+		Sessions: []models.SessionInfo{},
+	}
+
+	archivedSessions, archivedProjects, err := s.loadArchivedData()
+	if err != nil {
+		return listing, err
+	}
+
+	encodedPaths, err := s.source.Projects()
+	if err != nil {
+		return listing, fmt.Errorf("failed to read projects directory: %w", err)
+	}
+
+	for _, encodedPath := range encodedPaths {
+		if archivedProjects[encodedPath] {
+			sessions, err := s.scanProjectSessions(encodedPath, func(string) bool { return true }, nil)
+			if err != nil {
+				continue
+			}
+			listing.Projects = append(listing.Projects, models.Project{
+				EncodedPath: encodedPath,
+				DecodedPath: s.decodeProjectPath(encodedPath),
+				Sessions:    sessions,
+			})
+		}
+
+		sessions, err := s.scanProjectSessions(encodedPath, func(sessionID string) bool {
+			return archivedSessions[sessionID]
+		}, nil)
+		if err != nil {
+			continue
+		}
+		listing.Sessions = append(listing.Sessions, sessions...)
+	}
+
+	return listing, nil
+}
+
+// SetSessionsArchived archives or restores sessions and returns the IDs whose
+// state changed
+func (s *SessionService) SetSessionsArchived(sessionIDs []string, archived bool) ([]string, error) {
+	changed := []string{}
+	err := s.archive.Update(func(data *ArchiveData) error {
+		for _, id := range sessionIDs {
+			var ok bool
+			data.ArchivedSessions, ok = setMember(data.ArchivedSessions, id, archived)
+			if ok {
+				changed = append(changed, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// SetProjectArchived archives or restores a project and reports whether its
+// state changed
+func (s *SessionService) SetProjectArchived(encodedPath string, archived bool) (bool, error) {
+	var changed bool
+	err := s.archive.Update(func(data *ArchiveData) error {
+		data.ArchivedProjects, changed = setMember(data.ArchivedProjects, encodedPath, archived)
+		return nil
+	})
+	return changed, err
+}
+
+// setMember adds id to or removes it from list, reporting whether the list
+// changed
+func setMember(list []string, id string, member bool) ([]string, bool) {
+	i := slices.Index(list, id)
+	switch {
+	case member && i < 0:
+		return append(list, id), true
+	case !member && i >= 0:
+		return slices.Delete(list, i, i+1), true
+	}
+	return list, false
+}
+
+// SelectSessions returns the sessions matching a bulk selection. When
+// archived is set the selection is made among visible sessions, to archive
+// them; otherwise among archived ones, to restore them.
+func (s *SessionService) SelectSessions(selection models.ArchiveSelection, archived bool) ([]models.SessionInfo, error) {
+	if len(selection.SessionIDs) == 0 && selection.EncodedPath == "" && selection.Before.IsZero() && strings.TrimSpace(selection.Query) == "" {
+		return nil, fmt.Errorf("no sessions selected: %w", ErrInvalid)
+	}
+
+	var candidates []models.SessionInfo
+	if archived {
+		projects, err := s.GetAllProjects()
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			candidates = append(candidates, project.Sessions...)
+		}
+	} else {
+		listing, err := s.GetArchived()
+		if err != nil {
+			return nil, err
+		}
+		candidates = listing.Sessions
+	}
+
+	text, tags := splitTagQuery(selection.Query)
+	text = strings.ToLower(text)
+
+	selected := []models.SessionInfo{}
+	for _, info := range candidates {
+		if len(selection.SessionIDs) > 0 {
+			if slices.Contains(selection.SessionIDs, info.ID) {
+				selected = append(selected, info)
+			}
+			continue
+		}
+
+		if selection.EncodedPath != "" && info.EncodedPath != selection.EncodedPath {
+			continue
+		}
+		if !selection.Before.IsZero() {
+			last := info.EndTime
+			if last.IsZero() {
+				last = info.StartTime
+			}
+			if !last.Before(selection.Before) {
+				continue
+			}
+		}
+		if selection.Query != "" && !s.matchesQuery(info, text, tags) {
+			continue
+		}
+		selected = append(selected, info)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// deploySession is a short session a month after forkedSession
+var deploySession = jsonl(
+	`{"type":"user","uuid":"d1","parentUuid":null,"timestamp":"2026-02-01T10:00:00Z","cwd":"/tmp/app","message":{"role":"user","content":"Deploy the app"}}`,
+	`{"type":"assistant","uuid":"d2","parentUuid":"d1","timestamp":"2026-02-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"text","text":"Deployed"}]}}`,
+)
+
+func TestSelectSessions(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": forkedSession,
+		"-tmp-app/s2": deploySession,
+		"-tmp-web/s3": forkedSession,
+	})
+
+	tests := []struct {
+		name      string
+		selection models.ArchiveSelection
+		want      []string
+		wantErr   error
+	}{
+		{"nothing", models.ArchiveSelection{}, nil, ErrInvalid},
+		{"by ID", models.ArchiveSelection{SessionIDs: []string{"s2", "gone"}}, []string{"s2"}, nil},
+		{"by project", models.ArchiveSelection{EncodedPath: "-tmp-app"}, []string{"s1", "s2"}, nil},
+		{"before", models.ArchiveSelection{Before: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}, []string{"s1", "s3"}, nil},
+		{"query", models.ArchiveSelection{Query: "deploy"}, []string{"s2"}, nil},
+		{"project and before", models.ArchiveSelection{EncodedPath: "-tmp-web", Before: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}, []string{"s3"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := s.SelectSessions(tt.selection, true)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sessionIDs(selected); !slices.Equal(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": forkedSession,
+		"-tmp-app/s2": deploySession,
+		"-tmp-web/s3": forkedSession,
+	})
+
+	changed, err := s.SetSessionsArchived([]string{"s1", "s1"}, true)
+	if err != nil || !slices.Equal(changed, []string{"s1"}) {
+		t.Fatalf("SetSessionsArchived() = %v, %v; want [s1]", changed, err)
+	}
+	if changed, err := s.SetProjectArchived("-tmp-web", true); err != nil || !changed {
+		t.Fatalf("SetProjectArchived() = %v, %v", changed, err)
+	}
+	if changed, _ := s.SetProjectArchived("-tmp-web", true); changed {
+		t.Error("archiving an archived project reported a change")
+	}
+
+	projects, err := s.GetAllProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || !slices.Equal(sessionIDs(projects[0].Sessions), []string{"s2"}) {
+		t.Errorf("visible projects = %+v, want -tmp-app with s2 only", projects)
+	}
+
+	listing, err := s.GetArchived()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listing.Projects) != 1 || listing.Projects[0].EncodedPath != "-tmp-web" {
+		t.Errorf("archived projects = %+v, want -tmp-web", listing.Projects)
+	}
+	if got := sessionIDs(listing.Sessions); !slices.Equal(got, []string{"s1"}) {
+		t.Errorf("archived sessions = %v, want [s1]", got)
+	}
+
+	// Restoring selects among archived sessions only
+	selected, err := s.SelectSessions(models.ArchiveSelection{EncodedPath: "-tmp-app"}, false)
+	if err != nil || !slices.Equal(sessionIDs(selected), []string{"s1"}) {
+		t.Errorf("SelectSessions() to restore = %v, %v; want [s1]", sessionIDs(selected), err)
+	}
+	if changed, err := s.SetSessionsArchived([]string{"s1"}, false); err != nil || len(changed) != 1 {
+		t.Errorf("restoring s1 = %v, %v", changed, err)
+	}
+}
+
+// sessionIDs returns the IDs of sessions, sorted
+func sessionIDs(sessions []models.SessionInfo) []string {
+	ids := []string{}
+	for _, info := range sessions {
+		ids = append(ids, info.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+
+	// Decoders for thumbnails, besides PNG
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// maxImageBytes is the largest decoded image stored. Larger images are only
+// listed with their size and type.
+const maxImageBytes = 10 << 20
+
+// thumbnailSize bounds the width and height of thumbnails
+const thumbnailSize = 320
+
+// maxThumbnailPixels is the largest image decoded for a thumbnail. A small
+// file can declare huge dimensions, and decoding it would exhaust memory.
+const maxThumbnailPixels = 50_000_000
+
+// imageTypes are the content types served from the blob store. Anything else,
+// such as SVG, could run scripts when opened.
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+var blobHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// attachImages stores the image blocks of a message's content and records
+// them on the message, or on the tool result they were returned in
+func (s *SessionService) attachImages(msg *models.ConversationMessage, content interface{}) {
+	items, ok := content.([]interface{})
+	if !ok {
+		return
+	}
+
+	for i, item := range items {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch block["type"] {
+		case "image":
+			if ref, ok := s.messageImage(fmt.Sprintf("%s/%d", msg.UUID, i), msg.UUID != "", block); ok {
+				msg.Images = append(msg.Images, ref)
+			}
+		case "tool_result":
+			id, _ := block["tool_use_id"].(string)
+			nested, _ := block["content"].([]interface{})
+			for j, item := range nested {
+				block, ok := item.(map[string]interface{})
+				if !ok || block["type"] != "image" {
+					continue
+				}
+				ref, ok := s.messageImage(fmt.Sprintf("%s/%d/%d", msg.UUID, i, j), msg.UUID != "", block)
+				if !ok {
+					continue
+				}
+				for i := range msg.ToolResults {
+					if msg.ToolResults[i].ToolUseID == id {
+						msg.ToolResults[i].Images = append(msg.ToolResults[i].Images, ref)
+					}
+				}
+			}
+		}
+	}
+}
+
+// imageCache keeps what storeImage returned for the image blocks of messages,
+// by message uuid and position. Lines are never rewritten, so a message's
+// images only need decoding and hashing the first time it is read.
+type imageCache struct {
+	mu   sync.Mutex
+	refs map[string]cachedImage
+}
+
+type cachedImage struct {
+	ref models.ImageRef
+	ok  bool
+}
+
+// messageImage is storeImage for the image block at key, cached when the
+// message has a uuid to key it by
+func (s *SessionService) messageImage(key string, cache bool, block map[string]interface{}) (models.ImageRef, bool) {
+	if !cache {
+		return s.storeImage(block)
+	}
+
+	s.images.mu.Lock()
+	cached, found := s.images.refs[key]
+	s.images.mu.Unlock()
+	if found {
+		return cached.ref, cached.ok
+	}
+
+	ref, ok := s.storeImage(block)
+	s.images.mu.Lock()
+	if s.images.refs == nil {
+		s.images.refs = make(map[string]cachedImage)
+	}
+	s.images.refs[key] = cachedImage{ref: ref, ok: ok}
+	s.images.mu.Unlock()
+	return ref, ok
+}
+
+// hasImageBlock reports whether message content has an image block of its own
+func hasImageBlock(content interface{}) bool {
+	items, _ := content.([]interface{})
+	for _, item := range items {
+		if block, ok := item.(map[string]interface{}); ok && block["type"] == "image" {
+			return true
+		}
+	}
+	return false
+}
+
+// storeImage decodes a base64 image block and writes it to the blob store,
+// unless it is already there, too large or not a PNG, JPEG, GIF or WebP image
+func (s *SessionService) storeImage(block map[string]interface{}) (models.ImageRef, bool) {
+	source, _ := block["source"].(map[string]interface{})
+	if source["type"] != "base64" {
+		return models.ImageRef{}, false
+	}
+	encoded, _ := source["data"].(string)
+	mediaType, _ := source["media_type"].(string)
+
+	ref := models.ImageRef{MediaType: mediaType, Size: base64.StdEncoding.DecodedLen(len(encoded))}
+	if ref.Size > maxImageBytes {
+		ref.Omitted = true
+		return ref, true
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.ImageRef{}, false
+	}
+	ref.Size = len(data)
+	detected := http.DetectContentType(data)
+	if !slices.Contains(imageTypes, detected) {
+		ref.Omitted = true
+		return ref, true
+	}
+	// The media type in the session is only a claim; report what is served
+	ref.MediaType = detected
+	sum := sha256.Sum256(data)
+	ref.Hash = hex.EncodeToString(sum[:])
+
+	path := s.blobPath(ref.Hash)
+	if _, err := os.Stat(path); err == nil {
+		return ref, true
+	}
+	if err := writeBlob(path, data); err != nil {
+		s.logger.Printf("blobs: %v", err)
+	}
+	return ref, true
+}
+
+// OpenBlob returns the path and content type of a stored image, or of its
+// thumbnail, which is made on first use. Images that cannot be decoded, those
+// too large to decode safely and those already small enough are their own
+// thumbnail.
+func (s *SessionService) OpenBlob(hash string, thumbnail bool) (string, string, error) {
+	if !blobHash.MatchString(hash) {
+		return "", "", fmt.Errorf("blob %q: %w", hash, ErrInvalid)
+	}
+
+	path := s.blobPath(hash)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("blob %s: %w", hash, ErrNotFound)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(imageTypes, contentType) {
+		return "", "", fmt.Errorf("blob %s is %s, not an image: %w", hash, contentType, ErrInvalid)
+	}
+	if !thumbnail {
+		return path, contentType, nil
+	}
+
+	thumbPath := path + "-thumb.png"
+	if _, err := os.Stat(thumbPath); err == nil {
+		return thumbPath, "image/png", nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width*config.Height > maxThumbnailPixels {
+		return path, contentType, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return path, contentType, nil
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() <= thumbnailSize && bounds.Dy() <= thumbnailSize {
+		return path, contentType, nil
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, shrink(img, thumbnailSize)); err != nil {
+		return "", "", err
+	}
+	if err := writeBlob(thumbPath, encoded.Bytes()); err != nil {
+		return "", "", err
+	}
+	return thumbPath, "image/png", nil
+}
+
+// blobPath returns where an image is stored, in a blobs directory holding
+// images decoded from sessions named by the SHA-256 of their content
+func (s *SessionService) blobPath(hash string) string {
+	return filepath.Join(s.dataDir, "blobs", hash[:2], hash)
+}
+
+// writeBlob writes a file through a temporary file, so that a blob is either
+// complete or missing
+func writeBlob(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// shrink scales an image down to fit in a size by size square, averaging the
+// pixels each thumbnail pixel covers
+func shrink(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	scale := max(float64(w), float64(h)) / float64(size)
+	tw, th := max(int(float64(w)/scale), 1), max(int(float64(h)/scale), 1)
+
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := ty*h/th, max((ty+1)*h/th, ty*h/th+1)
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := tx*w/tw, max((tx+1)*w/tw, tx*w/tw+1)
+
+			// Colors are alpha-premultiplied, so they can be averaged directly
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			thumb.SetRGBA(tx, ty, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return thumb
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// testPNG encodes a blank PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestOpenBlobThumbnails(t *testing.T) {
+	// A 1x1 PNG whose header claims 100000x100000 pixels
+	bomb := testPNG(t, 1, 1)
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		thumbnail bool // whether a thumbnail is made
+	}{
+		{"large", testPNG(t, 800, 400), true},
+		{"small", testPNG(t, 100, 100), false},
+		{"too many pixels", bomb, false},
+	}
+
+	s := newTestService(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := sha256.Sum256(tt.data)
+			hash := hex.EncodeToString(sum[:])
+			if err := writeBlob(s.blobPath(hash), tt.data); err != nil {
+				t.Fatal(err)
+			}
+
+			path, contentType, err := s.OpenBlob(hash, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if made := path != s.blobPath(hash); made != tt.thumbnail {
+				t.Errorf("OpenBlob() = %s, thumbnail made: %v, want %v", path, made, tt.thumbnail)
+			}
+			if contentType != "image/png" {
+				t.Errorf("content type = %s", contentType)
+			}
+		})
+	}
+}
+
+func TestGetSessionStoresImagesOnce(t *testing.T) {
+	data := testPNG(t, 2, 2)
+	encoded := base64.StdEncoding.EncodeToString(data)
+	session := jsonl(
+		`{"type":"user","uuid":"p1","timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":[{"type":"text","text":"Look"},{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"`+encoded+`"}}]}}`,
+		`{"type":"user","uuid":"r1","timestamp":"2026-01-01T10:00:01Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"PHN2Zz48L3N2Zz4="}}]}]}}`,
+	)
+	s := newTestService(t, map[string]string{"-tmp-app/s1": session})
+
+	first, err := s.GetSession("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Messages) != 2 || len(first.Messages[0].Images) != 1 {
+		t.Fatalf("got %+v, want one image on the prompt", first.Messages)
+	}
+	ref := first.Messages[0].Images[0]
+	if ref.MediaType != "image/png" || ref.Hash == "" || ref.Size != len(data) {
+		t.Errorf("image = %+v, want the stored PNG with its detected type", ref)
+	}
+	if _, err := os.Stat(s.blobPath(ref.Hash)); err != nil {
+		t.Errorf("image was not stored: %v", err)
+	}
+
+	// SVG is not served, even when returned by a tool
+	if got := first.Messages[1].ToolResults[0].Images; len(got) != 1 || !got[0].Omitted {
+		t.Errorf("tool result images = %+v, want one omitted image", got)
+	}
+
+	// Reading the session again reuses what was stored
+	if err := os.Remove(s.blobPath(ref.Hash)); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.GetSession("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Messages[0].Images) != 1 || second.Messages[0].Images[0] != ref {
+		t.Errorf("images on second read = %+v, want %+v", second.Messages[0].Images, ref)
+	}
+	if _, err := os.Stat(s.blobPath(ref.Hash)); !os.IsNotExist(err) {
+		t.Errorf("the image was decoded and stored again on the second read")
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+)
+
+// GetSessionChangeset replays the Edit, MultiEdit and Write calls of a session
+// and returns the net change of every file it edited, with a patch for
+// git apply from the session's working directory. Files whose content before
+// the session is unknown, or that are outside that directory, cannot be
+// applied and are left out of the patch.
+func (s *SessionService) GetSessionChangeset(encodedPath, sessionID string) (models.Changeset, error) {
+	if !sources.IsPlainName(encodedPath) || !sources.IsPlainName(sessionID) {
+		return models.Changeset{}, fmt.Errorf("session %s: %w", sessionID, ErrInvalid)
+	}
+
+	session, changes, err := s.readSession(encodedPath, sessionID, nil, parseFull)
+	if errors.Is(err, fs.ErrNotExist) {
+		return models.Changeset{}, fmt.Errorf("session %s: %w", sessionID, ErrNotFound)
+	}
+	if err != nil {
+		return models.Changeset{}, err
+	}
+
+	changeset := models.Changeset{SessionID: sessionID, Files: []models.FileChange{}}
+	// git apply ignores the lines before the first diff, so the session and
+	// the files left out are noted there
+	var notes, diffs strings.Builder
+	fmt.Fprintf(&notes, "# Session %s (%s)\n", sessionID, projectRoot(session))
+
+	for _, change := range changes {
+		file := models.FileChange{Path: change.path, Edits: change.edits}
+		file.Diff.Path = change.path
+		file.Diff.Created = change.created
+
+		switch {
+		case filepath.IsAbs(change.path):
+			file.Reason = "outside the project"
+		case !change.baseKnown || change.final == nil:
+			file.Reason = "content before the session is unknown"
+		default:
+			file.Applicable = true
+		}
+
+		if file.Applicable {
+			hunks, added, removed := unifiedHunks(diffLines(splitLines(change.base), splitLines(*change.final)), 1)
+			file.Diff.Patch = diffHeader(change.path, change.created) + hunks
+			file.Diff.Added, file.Diff.Removed = added, removed
+			if hunks != "" {
+				diffs.WriteString(gitDiffHeader(change.path, change.created))
+				diffs.WriteString(file.Diff.Patch)
+			}
+		} else {
+			// Only the edits themselves are known, so they are shown one after another
+			file.Diff.Patch = diffHeader(change.path, change.created) + strings.Join(change.hunks, "")
+			file.Diff.Added, file.Diff.Removed = change.added, change.removed
+			file.Diff.Snippet = true
+			fmt.Fprintf(&notes, "# Skipped %s: %s\n", change.path, file.Reason)
+		}
+
+		changeset.Files = append(changeset.Files, file)
+	}
+
+	changeset.Patch = notes.String() + diffs.String()
+	return changeset, nil
+}
+
+// gitDiffHeader returns the line git starts the diff of a file with
+func gitDiffHeader(path string, created bool) string {
+	header := fmt.Sprintf("diff --git a/%s b/%s\n", path, path)
+	if created {
+		header += "new file mode 100644\n"
+	}
+	return header
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGetSessionChangeset(t *testing.T) {
+	tool := func(uuid, parent, id, name, input string) string {
+		return `{"type":"assistant","uuid":"` + uuid + `","parentUuid":"` + parent + `","timestamp":"2026-01-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"` + id + `","name":"` + name + `","input":` + input + `}]}}`
+	}
+	result := func(uuid, parent, id, content string, isError bool, extra string) string {
+		errField := ""
+		if isError {
+			errField = `,"is_error":true`
+		}
+		return `{"type":"user","uuid":"` + uuid + `","parentUuid":"` + parent + `","timestamp":"2026-01-01T10:00:02Z"` + extra + `,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"` + id + `","content":"` + content + `"` + errField + `}]}}`
+	}
+	s := newTestService(t, map[string]string{"-tmp-app/s1": jsonl(
+		`{"type":"user","uuid":"p1","parentUuid":null,"timestamp":"2026-01-01T10:00:00Z","cwd":"/tmp/app","message":{"role":"user","content":"Change x"}}`,
+		tool("a1", "p1", "t1", "Read", `{"file_path":"/tmp/app/a.go"}`),
+		result("r1", "a1", "t1", `     1→package a\n     2→var x = 1`, false, ""),
+		tool("a2", "r1", "t2", "Edit", `{"file_path":"/tmp/app/a.go","old_string":"x = 1","new_string":"x = 2"}`),
+		result("r2", "a2", "t2", "ok", false, ""),
+		tool("a3", "r2", "t3", "Edit", `{"file_path":"/tmp/app/a.go","old_string":"x = 2","new_string":"x = 3"}`),
+		result("r3", "a3", "t3", "old_string not found", true, ""),
+		tool("a4", "r3", "t4", "Edit", `{"file_path":"/tmp/app/b.go","old_string":"y","new_string":"z"}`),
+		result("r4", "a4", "t4", "ok", false, ""),
+		tool("a5", "r4", "t5", "Write", `{"file_path":"/tmp/app/c.go","content":"package c\n"}`),
+		result("r5", "a5", "t5", "ok", false, `,"toolUseResult":{"type":"create","filePath":"/tmp/app/c.go","content":"package c\n"}`),
+		tool("a6", "r5", "t6", "Edit", `{"file_path":"/etc/hosts","old_string":"a","new_string":"b"}`),
+		result("r6", "a6", "t6", "ok", false, ""),
+	)})
+
+	changeset, err := s.GetSessionChangeset("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		applicable bool
+		reason     string
+		created    bool
+		edits      int
+	}{
+		{"a.go", true, "", false, 1},
+		{"b.go", false, "content before the session is unknown", false, 1},
+		{"c.go", true, "", true, 1},
+		{"/etc/hosts", false, "outside the project", false, 1},
+	}
+	if len(changeset.Files) != len(tests) {
+		t.Fatalf("got %d files, want %d: %+v", len(changeset.Files), len(tests), changeset.Files)
+	}
+	for i, tt := range tests {
+		file := changeset.Files[i]
+		if file.Path != tt.path || file.Applicable != tt.applicable || file.Reason != tt.reason ||
+			file.Diff.Created != tt.created || file.Edits != tt.edits {
+			t.Errorf("file %d = %s applicable %v (%q) created %v with %d edits; want %+v",
+				i, file.Path, file.Applicable, file.Reason, file.Diff.Created, file.Edits, tt)
+		}
+	}
+
+	got, err := applyHunks("package a\nvar x = 1\n", changeset.Files[0].Diff.Patch[strings.Index(changeset.Files[0].Diff.Patch, "@@"):])
+	if err != nil || got != "package a\nvar x = 2\n" {
+		t.Errorf("applying the a.go diff gives %q, %v", got, err)
+	}
+
+	for _, want := range []string{"diff --git a/a.go b/a.go\n", "diff --git a/c.go b/c.go\nnew file mode 100644\n", "# Skipped b.go: ", "# Skipped /etc/hosts: "} {
+		if !strings.Contains(changeset.Patch, want) {
+			t.Errorf("patch misses %q:\n%s", want, changeset.Patch)
+		}
+	}
+	if strings.Contains(changeset.Patch, "diff --git a/b.go") {
+		t.Errorf("patch includes b.go, whose content before the session is unknown:\n%s", changeset.Patch)
+	}
+
+	if _, err := s.GetSessionChangeset("-tmp-app", "../s1"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("GetSessionChangeset(../s1) error = %v, want ErrInvalid", err)
+	}
+	if _, err := s.GetSessionChangeset("-tmp-app", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSessionChangeset(gone) error = %v, want ErrNotFound", err)
+	}
+}
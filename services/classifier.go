@@ -0,0 +1,77 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// Markers Claude Code uses for content it injects as user messages
+var (
+	commandOutputTags = []string{
+		"<local-command-stdout>",
+		"<local-command-stderr>",
+		"<bash-input>",
+		"<bash-stdout>",
+		"<bash-stderr>",
+	}
+	// A slash command starts with its name, or with its message in older
+	// Claude Code versions
+	slashCommandTags = []string{
+		"<command-name>",
+		"<command-message>",
+	}
+	// Hook output starts with the hook's event, such as "Stop hook feedback:"
+	// or "PreToolUse:Bash hook blocking error from command: ..."
+	hookPrefix   = regexp.MustCompile(`^(<user-prompt-submit-hook>|[A-Za-z]+(:\S+)? hook (feedback:|blocking error))`)
+	metaPrefixes = []string{
+		"<system-reminder>",
+		"Caveat: The messages below were generated by the user while running local commands",
+		"This session is being continued from a previous conversation",
+	}
+)
+
+// ClassifyMessage decides what kind of message a JSONL line holds, based on
+// its flags and its text and tool result content
+func ClassifyMessage(line models.JSONLMessage, content string, toolResults []models.ToolResult) models.MessageKind {
+	if line.Message != nil && line.Message.Role == "assistant" {
+		return models.KindAssistant
+	}
+
+	text := strings.TrimSpace(content)
+
+	if len(toolResults) > 0 && text == "" {
+		return models.KindToolResult
+	}
+	if line.IsMeta || line.IsCompactSummary {
+		return models.KindMeta
+	}
+	if strings.HasPrefix(text, "[Request interrupted by user") {
+		return models.KindInterrupted
+	}
+	for _, tag := range slashCommandTags {
+		if strings.HasPrefix(text, tag) {
+			return models.KindSlashCommand
+		}
+	}
+	for _, tag := range commandOutputTags {
+		if strings.HasPrefix(text, tag) {
+			return models.KindCommandOutput
+		}
+	}
+	if hookPrefix.MatchString(text) {
+		return models.KindHook
+	}
+	for _, prefix := range metaPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return models.KindMeta
+		}
+	}
+	// A prompt may be nothing but a pasted image
+	if text == "" && (line.Message == nil || !hasImageBlock(line.Message.Content)) {
+		return models.KindMeta
+	}
+
+	return models.KindPrompt
+}
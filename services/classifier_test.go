@@ -0,0 +1,53 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want models.MessageKind
+	}{
+		{"prompt", `{"type":"user","message":{"role":"user","content":"Fix the parser"}}`, models.KindPrompt},
+		{"prompt in text blocks", `{"type":"user","message":{"role":"user","content":[{"type":"text","text":"Fix the parser"}]}}`, models.KindPrompt},
+		{"pasted image only", `{"type":"user","message":{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":""}}]}}`, models.KindPrompt},
+		{"assistant", `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}`, models.KindAssistant},
+		{"tool result", `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`, models.KindToolResult},
+		{"meta flag", `{"type":"user","isMeta":true,"message":{"role":"user","content":"Caveat"}}`, models.KindMeta},
+		{"compact summary", `{"type":"user","isCompactSummary":true,"message":{"role":"user","content":"Summary"}}`, models.KindMeta},
+		{"system reminder", `{"type":"user","message":{"role":"user","content":"<system-reminder>Be brief</system-reminder>"}}`, models.KindMeta},
+		{"continued session", `{"type":"user","message":{"role":"user","content":"This session is being continued from a previous conversation"}}`, models.KindMeta},
+		{"empty", `{"type":"user","message":{"role":"user","content":"  "}}`, models.KindMeta},
+		{"interrupted", `{"type":"user","message":{"role":"user","content":"[Request interrupted by user for tool use]"}}`, models.KindInterrupted},
+		{"slash command", `{"type":"user","message":{"role":"user","content":"<command-name>/review</command-name>"}}`, models.KindSlashCommand},
+		{"command output", `{"type":"user","message":{"role":"user","content":"<local-command-stdout>ok</local-command-stdout>"}}`, models.KindCommandOutput},
+		{"bash mode", `{"type":"user","message":{"role":"user","content":"<bash-input>ls</bash-input>"}}`, models.KindCommandOutput},
+		{"slash command message first", `{"type":"user","message":{"role":"user","content":"<command-message>review is running</command-message>\n<command-name>/review</command-name>"}}`, models.KindSlashCommand},
+		{"prompt mentioning a command tag", `{"type":"user","message":{"role":"user","content":"Why does the parser look for <command-name> tags?"}}`, models.KindPrompt},
+		{"hook", `{"type":"user","message":{"role":"user","content":"Stop hook feedback: tests fail"}}`, models.KindHook},
+		{"hook blocking error", `{"type":"user","message":{"role":"user","content":"PreToolUse:Bash hook blocking error from command: \"./check.sh\""}}`, models.KindHook},
+		{"prompt submit hook", `{"type":"user","message":{"role":"user","content":"<user-prompt-submit-hook>Remember the style guide</user-prompt-submit-hook>"}}`, models.KindHook},
+		{"prompt quoting hook output", `{"type":"user","message":{"role":"user","content":"I keep getting \"Stop hook feedback:\" after every answer"}}`, models.KindPrompt},
+	}
+
+	s := &SessionService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var line models.JSONLMessage
+			if err := json.Unmarshal([]byte(tt.line), &line); err != nil {
+				t.Fatal(err)
+			}
+			content := s.extractContent(line.Message.Content)
+			_, results := extractToolBlocks(line.Message.Content)
+
+			if got := ClassifyMessage(line, content, results); got != tt.want {
+				t.Errorf("ClassifyMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
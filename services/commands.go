@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+var (
+	commandNamePattern = regexp.MustCompile(`(?s)<command-name>\s*(.*?)\s*</command-name>`)
+	commandArgsPattern = regexp.MustCompile(`(?s)<command-args>(.*?)</command-args>`)
+)
+
+// ParseSlashCommand parses the <command-name> and <command-args> tags Claude
+// Code records for a slash command. It returns nil if content has none.
+func ParseSlashCommand(content string) *models.SlashCommand {
+	match := commandNamePattern.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	cmd := &models.SlashCommand{
+		Name: strings.TrimPrefix(match[1], "/"),
+	}
+	if args := commandArgsPattern.FindStringSubmatch(content); args != nil {
+		cmd.Args = strings.TrimSpace(args[1])
+	}
+	return cmd
+}
+
+// PromptText returns what the user typed for a prompt, rendering slash
+// commands as "/name args" instead of their raw tags
+func PromptText(msg models.ConversationMessage) string {
+	if msg.Command == nil {
+		return msg.Content
+	}
+	if msg.Command.Args == "" {
+		return "/" + msg.Command.Name
+	}
+	return "/" + msg.Command.Name + " " + msg.Command.Args
+}
+
+// linkCommands fills in the parsed command of every slash command message:
+// the expanded body from the meta message that follows it and the command
+// file it came from
+func (s *SessionService) linkCommands(messages []models.ConversationMessage, projectPath string) {
+	// Commands are often run several times in a session; look each file up once
+	type commandPath struct{ path, scope string }
+	found := make(map[string]commandPath)
+
+	for i := range messages {
+		if messages[i].Kind != models.KindSlashCommand {
+			continue
+		}
+
+		cmd := ParseSlashCommand(messages[i].Content)
+		if cmd == nil {
+			continue
+		}
+
+		for _, next := range messages[i+1:] {
+			if next.ParentUUID != messages[i].UUID {
+				continue
+			}
+			if next.Kind == models.KindMeta {
+				cmd.Body = next.Content
+			}
+			break
+		}
+
+		file, ok := found[cmd.Name]
+		if !ok {
+			file.path, file.scope = s.commandFile(cmd.Name, projectPath)
+			found[cmd.Name] = file
+		}
+		cmd.FilePath, cmd.Scope = file.path, file.scope
+		messages[i].Command = cmd
+	}
+}
+
+// commandFile returns the path of the Markdown file defining a custom command,
+// looking at the project's .claude/commands before the user-level one.
+// Namespaced commands like "frontend:component" live in subdirectories.
+func (s *SessionService) commandFile(name, projectPath string) (string, string) {
+	rel := filepath.Join(strings.Split(name, ":")...) + ".md"
+
+	type candidate struct{ dir, scope string }
+	var candidates []candidate
+	if projectPath != "" {
+		candidates = append(candidates, candidate{filepath.Join(projectPath, ".claude", "commands"), "project"})
+	}
+	if s.claudeDir != "" {
+		candidates = append(candidates, candidate{filepath.Join(s.claudeDir, "commands"), "user"})
+	}
+
+	for _, c := range candidates {
+		path := filepath.Join(c.dir, rel)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, c.scope
+		}
+	}
+	return "", ""
+}
+
+// listUserCommands returns the names of the commands defined under the
+// user-level commands folder
+func (s *SessionService) listUserCommands() map[string]string {
+	commands := make(map[string]string)
+	if s.claudeDir == "" {
+		return commands
+	}
+	commandsDir := filepath.Join(s.claudeDir, "commands")
+
+	filepath.WalkDir(commandsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(commandsDir, path)
+		if err != nil {
+			return nil
+		}
+		name := strings.ReplaceAll(strings.TrimSuffix(rel, ".md"), string(filepath.Separator), ":")
+		commands[name] = path
+		return nil
+	})
+
+	return commands
+}
+
+// GetCommandUsage returns usage statistics for every slash command invoked
+// in any session, plus the user-level commands that were never used
+func (s *SessionService) GetCommandUsage() ([]models.CommandUsage, error) {
+	usage := make(map[string]*models.CommandUsage)
+	get := func(name string) *models.CommandUsage {
+		if u, ok := usage[name]; ok {
+			return u
+		}
+		u := &models.CommandUsage{Name: name}
+		usage[name] = u
+		return u
+	}
+
+	for name, path := range s.listUserCommands() {
+		u := get(name)
+		u.FilePath = path
+		u.Scope = "user"
+	}
+
+	_, err := s.walkSessions(func(project models.Project, info models.SessionInfo, session models.Session) {
+		projectName := filepath.Base(project.DecodedPath)
+		used := make(map[string]bool)
+		for _, msg := range session.Messages {
+			if msg.Command == nil {
+				continue
+			}
+
+			u := get(msg.Command.Name)
+			u.Count++
+			if msg.Timestamp.After(u.LastUsed) {
+				u.LastUsed = msg.Timestamp
+			}
+			if u.FilePath == "" {
+				u.FilePath, u.Scope = msg.Command.FilePath, msg.Command.Scope
+			}
+			if !used[u.Name] {
+				used[u.Name] = true
+				u.SessionCount++
+				if !slices.Contains(u.Projects, projectName) {
+					u.Projects = append(u.Projects, projectName)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []models.CommandUsage
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+
+	// Most used first, then by name
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+var commandNameValid = regexp.MustCompile(`^[A-Za-z0-9_-]+(:[A-Za-z0-9_-]+)*$`)
+
+// RenderCommandFile renders a draft into the Markdown command file it would
+// produce, and reports where it would be written and whether a file is
+// already there
+func (s *SessionService) RenderCommandFile(draft models.CommandDraft) (models.CommandFile, error) {
+	if !commandNameValid.MatchString(draft.Name) {
+		return models.CommandFile{}, fmt.Errorf("command name %q: %w", draft.Name, ErrInvalid)
+	}
+	if strings.TrimSpace(draft.Content) == "" {
+		return models.CommandFile{}, fmt.Errorf("command content is empty: %w", ErrInvalid)
+	}
+
+	dir, err := s.commandsDir(draft.Scope, draft.EncodedPath)
+	if err != nil {
+		return models.CommandFile{}, err
+	}
+	path := filepath.Join(dir, filepath.Join(strings.Split(draft.Name, ":")...)+".md")
+
+	var b strings.Builder
+	if draft.Description != "" || draft.ArgumentHint != "" {
+		b.WriteString("---\n")
+		if draft.Description != "" {
+			fmt.Fprintf(&b, "description: %s\n", yamlString(draft.Description))
+		}
+		if draft.ArgumentHint != "" {
+			fmt.Fprintf(&b, "argument-hint: %s\n", yamlString(draft.ArgumentHint))
+		}
+		b.WriteString("---\n\n")
+	}
+	b.WriteString(strings.TrimSpace(draft.Content))
+	b.WriteString("\n")
+
+	_, statErr := os.Stat(path)
+	return models.CommandFile{
+		Path:     path,
+		Markdown: b.String(),
+		Exists:   statErr == nil,
+	}, nil
+}
+
+// SaveCommandFile writes a draft as a custom slash command. It refuses to
+// replace an existing command unless overwrite is set.
+func (s *SessionService) SaveCommandFile(draft models.CommandDraft, overwrite bool) (models.CommandFile, error) {
+	if s.ReadOnly() {
+		return models.CommandFile{}, fmt.Errorf("cannot save command file: %w", ErrReadOnly)
+	}
+
+	file, err := s.RenderCommandFile(draft)
+	if err != nil {
+		return models.CommandFile{}, err
+	}
+	if file.Exists && !overwrite {
+		return file, fmt.Errorf("command file %s: %w", file.Path, ErrConflict)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+		return models.CommandFile{}, fmt.Errorf("failed to create commands dir: %w", err)
+	}
+	if err := os.WriteFile(file.Path, []byte(file.Markdown), 0644); err != nil {
+		return models.CommandFile{}, fmt.Errorf("failed to write command file: %w", err)
+	}
+
+	file.Exists = true
+	return file, nil
+}
+
+// commandsDir returns the commands folder for the given scope
+func (s *SessionService) commandsDir(scope, encodedPath string) (string, error) {
+	switch scope {
+	case "", "user":
+		// Sources that are not a local .claude directory have no user folder
+		if s.claudeDir == "" {
+			return "", fmt.Errorf("no user commands folder: %w", ErrReadOnly)
+		}
+		return filepath.Join(s.claudeDir, "commands"), nil
+	case "project":
+		if encodedPath == "" {
+			return "", fmt.Errorf("project is not set: %w", ErrInvalid)
+		}
+		projectPath := s.projectWorkDir(encodedPath)
+		if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("project directory %s not found: %w", projectPath, ErrInvalid)
+		}
+		return filepath.Join(projectPath, ".claude", "commands"), nil
+	default:
+		return "", fmt.Errorf("scope %q: %w", scope, ErrInvalid)
+	}
+}
+
+// yamlString quotes a frontmatter value when it could be misread as YAML
+// syntax: when it holds an indicator character or starts like a sequence
+// entry, a mapping key or null
+func yamlString(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "\n", " ")
+	if strings.ContainsAny(value, ":#[]{},&*!|>'\"%@`") || strings.IndexAny(value, "-?~") == 0 {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestRenderCommandFileWithoutLocalClaudeDir(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	draft := models.CommandDraft{Name: "review", Content: "Review the diff", Scope: "user"}
+	if _, err := s.RenderCommandFile(draft); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("RenderCommandFile() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Review the diff", "Review the diff"},
+		{"差分をレビューする", "差分をレビューする"},
+		{"Fix: the parser", `"Fix: the parser"`},
+		{"[file]", `"[file]"`},
+		{"- list item", `"- list item"`},
+		{"? key", `"? key"`},
+		{"~", `"~"`},
+		{"well-known", "well-known"},
+		{"two\nlines", "two lines"},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGetCommandUsage(t *testing.T) {
+	review := func(uuid, timestamp string) string {
+		return `{"type":"user","uuid":"` + uuid + `","timestamp":"` + timestamp + `","cwd":"/tmp/app","message":{"role":"user","content":"<command-message>review is running</command-message>\n<command-name>/review</command-name>\n<command-args>main</command-args>"}}`
+	}
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": jsonl(review("c1", "2026-01-01T10:00:00Z"), review("c2", "2026-01-02T10:00:00Z")),
+		"-tmp-web/s2": jsonl(review("c3", "2026-01-03T10:00:00Z")),
+		"-tmp-web/s3": forkedSession,
+	})
+
+	usage, err := s.GetCommandUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("got %+v, want only /review", usage)
+	}
+	u := usage[0]
+	if u.Name != "review" || u.Count != 3 || u.SessionCount != 2 {
+		t.Errorf("usage = %+v, want review run 3 times in 2 sessions", u)
+	}
+	slices.Sort(u.Projects)
+	if !slices.Equal(u.Projects, []string{"app", "web"}) {
+		t.Errorf("projects = %v, want [app web]", u.Projects)
+	}
+	if got := u.LastUsed.Format("2006-01-02"); got != "2026-01-03" {
+		t.Errorf("last used = %s, want 2026-01-03", got)
+	}
+}
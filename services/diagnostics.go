@@ -0,0 +1,107 @@
+package services
+
+import (
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// maxLineErrors bounds the line errors kept per file. A format change can
+// break every line of a file, and the first few say what went wrong.
+const maxLineErrors = 20
+
+// knownLineTypes are the line types of session files that the parser reads
+// or knowingly ignores
+var knownLineTypes = map[string]bool{
+	"user":                  true,
+	"assistant":             true,
+	"summary":               true,
+	"system":                true,
+	"attachment":            true,
+	"file-history-snapshot": true,
+	"queue-operation":       true,
+}
+
+// knownBlockTypes are the content block types of messages and tool results
+// that the parser reads or knowingly ignores
+var knownBlockTypes = map[string]bool{
+	"text":              true,
+	"image":             true,
+	"document":          true,
+	"tool_use":          true,
+	"tool_result":       true,
+	"thinking":          true,
+	"redacted_thinking": true,
+}
+
+// diagnosticsCollector gathers the parse diagnostics of a session file
+type diagnosticsCollector struct {
+	diagnostics models.ParseDiagnostics
+	types       map[string]int // index in UnknownTypes
+	blocks      map[string]int // index in UnknownBlocks
+}
+
+func newDiagnosticsCollector() *diagnosticsCollector {
+	return &diagnosticsCollector{
+		diagnostics: models.ParseDiagnostics{
+			LineErrors:    []models.LineError{},
+			UnknownTypes:  []models.UnknownType{},
+			UnknownBlocks: []models.UnknownType{},
+		},
+		types:  make(map[string]int),
+		blocks: make(map[string]int),
+	}
+}
+
+// lineError records a line that could not be parsed
+func (d *diagnosticsCollector) lineError(number int, err error) {
+	d.diagnostics.SkippedLines++
+	if len(d.diagnostics.LineErrors) < maxLineErrors {
+		d.diagnostics.LineErrors = append(d.diagnostics.LineErrors, models.LineError{Line: number, Error: err.Error()})
+	}
+}
+
+// add records the unknown line and content block types of a parsed line
+func (d *diagnosticsCollector) add(number int, msg models.JSONLMessage) {
+	if !knownLineTypes[msg.Type] {
+		countUnknown(&d.diagnostics.UnknownTypes, d.types, msg.Type, number)
+	}
+	if msg.Message == nil {
+		return
+	}
+
+	items, _ := msg.Message.Content.([]interface{})
+	for _, item := range items {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		d.block(block, number)
+
+		if block["type"] == "tool_result" {
+			nested, _ := block["content"].([]interface{})
+			for _, item := range nested {
+				if block, ok := item.(map[string]interface{}); ok {
+					d.block(block, number)
+				}
+			}
+		}
+	}
+}
+
+func (d *diagnosticsCollector) block(block map[string]interface{}, number int) {
+	blockType, _ := block["type"].(string)
+	if !knownBlockTypes[blockType] {
+		countUnknown(&d.diagnostics.UnknownBlocks, d.blocks, blockType, number)
+	}
+}
+
+// countUnknown counts an occurrence of an unknown type, keeping types in the
+// order they first occurred
+func countUnknown(unknown *[]models.UnknownType, index map[string]int, typ string, number int) {
+	i, ok := index[typ]
+	if !ok {
+		i = len(*unknown)
+		index[typ] = i
+		*unknown = append(*unknown, models.UnknownType{Type: typ, FirstLine: number})
+	}
+	(*unknown)[i].Count++
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedHunksApply(t *testing.T) {
+	numbered := func(from, to int) string {
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			fmt.Fprintf(&b, "line %d\n", i)
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"replace one line", "a\nb\nc\n", "a\nB\nc\n"},
+		{"insert at start", "a\nb\n", "x\na\nb\n"},
+		{"append", "a\nb\n", "a\nb\nc\n"},
+		{"delete all", "a\nb\n", ""},
+		{"create", "", "a\nb\n"},
+		{"no newline at end", "a\nb", "a\nc"},
+		{"add newline at end", "a\nb", "a\nb\n"},
+		{"distant changes", numbered(1, 30), strings.Replace(strings.Replace(numbered(1, 30), "line 2\n", "two\n", 1), "line 28\n", "", 1)},
+		{"close changes", numbered(1, 12), strings.Replace(strings.Replace(numbered(1, 12), "line 3\n", "three\n", 1), "line 8\n", "eight\n", 1)},
+		{"repeated lines", "x\nx\ny\nx\n", "x\ny\nx\nx\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := diffLines(splitLines(tt.old), splitLines(tt.new))
+			patch, added, removed := unifiedHunks(ops, 1)
+
+			got, err := applyHunks(tt.old, patch)
+			if err != nil {
+				t.Fatalf("%v in patch:\n%s", err, patch)
+			}
+			if got != tt.new {
+				t.Errorf("applying the patch gives %q, want %q; patch:\n%s", got, tt.new, patch)
+			}
+
+			var wantAdded, wantRemoved int
+			for _, op := range ops {
+				switch op.kind {
+				case '+':
+					wantAdded++
+				case '-':
+					wantRemoved++
+				}
+			}
+			if added != wantAdded || removed != wantRemoved {
+				t.Errorf("counted +%d -%d, the edit script has +%d -%d", added, removed, wantAdded, wantRemoved)
+			}
+		})
+	}
+}
+
+func TestUnifiedHunksFormat(t *testing.T) {
+	ops := diffLines(splitLines("a\nb\nc\n"), splitLines("a\nB\nc\n"))
+	patch, _, _ := unifiedHunks(ops, 10)
+
+	want := "@@ -10,3 +10,3 @@\n a\n-b\n+B\n c\n"
+	if patch != want {
+		t.Errorf("patch = %q, want %q", patch, want)
+	}
+}
+
+// applyHunks applies unified diff hunks to text the way patch does, checking
+// that context and removed lines match and that the ranges are right
+func applyHunks(text, patch string) (string, error) {
+	old := splitLines(text)
+	var out []string
+	next := 0 // index of the first line of old not yet copied
+
+	lines := splitLines(patch)
+	for i := 0; i < len(lines); {
+		var oldStart, oldCount, newStart, newCount int
+		if _, err := fmt.Sscanf(normalizeHunkHeader(lines[i]), "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount); err != nil {
+			return "", fmt.Errorf("bad hunk header %q: %w", lines[i], err)
+		}
+		i++
+
+		// An empty side starts at the line before it
+		at := oldStart - 1
+		if oldCount == 0 {
+			at = oldStart
+		}
+		if at < next || at > len(old) {
+			return "", fmt.Errorf("hunk at line %d is out of order", oldStart)
+		}
+		out = append(out, old[next:at]...)
+		next = at
+
+		seenOld, seenNew := 0, 0
+		for i < len(lines) && !strings.HasPrefix(lines[i], "@@") {
+			line := lines[i]
+			i++
+			// The marker ends the line before it without a newline
+			if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+				line = strings.TrimSuffix(line, "\n")
+				i++
+			}
+
+			kind, content := line[0], line[1:]
+			if kind != '+' {
+				if next >= len(old) || old[next] != content {
+					return "", fmt.Errorf("line %d does not match %q", next+1, content)
+				}
+				next++
+				seenOld++
+			}
+			if kind != '-' {
+				out = append(out, content)
+				seenNew++
+			}
+		}
+		if seenOld != oldCount || seenNew != newCount {
+			return "", fmt.Errorf("hunk at line %d has -%d +%d lines, header says -%d +%d", oldStart, seenOld, seenNew, oldCount, newCount)
+		}
+	}
+
+	out = append(out, old[next:]...)
+	return strings.Join(out, ""), nil
+}
+
+// normalizeHunkHeader writes out the line counts that a header leaves out
+// when they are 1
+func normalizeHunkHeader(header string) string {
+	fields := strings.Fields(header)
+	if len(fields) < 4 {
+		return header
+	}
+	for _, i := range []int{1, 2} {
+		if !strings.Contains(fields[i], ",") {
+			fields[i] += ",1"
+		}
+	}
+	return strings.Join(fields, " ")
+}
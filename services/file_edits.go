@@ -0,0 +1,249 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// editOrigin is what Claude Code recorded in toolUseResult about a file
+// before an Edit, MultiEdit or Write changed it
+type editOrigin struct {
+	content *string
+	created bool
+}
+
+// parseEditOrigin decodes the toolUseResult of a tool result line
+func parseEditOrigin(raw json.RawMessage) (editOrigin, bool) {
+	var result struct {
+		Type         string  `json:"type"`
+		OriginalFile *string `json:"originalFile"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &result) != nil {
+		return editOrigin{}, false
+	}
+	if result.OriginalFile == nil && result.Type != "create" {
+		return editOrigin{}, false
+	}
+	return editOrigin{content: result.OriginalFile, created: result.Type == "create"}, true
+}
+
+// readOutputLine matches a numbered line of Read output, "     1→text" or
+// "     1\ttext" depending on the Claude Code version
+var readOutputLine = regexp.MustCompile(`^\s*(\d+)(?:→|\t)(.*)$`)
+
+// parseReadOutput recovers file content from the result of a Read call that
+// started at the first line
+func parseReadOutput(output string) (string, bool) {
+	var b strings.Builder
+	next := 1
+	for _, line := range strings.Split(output, "\n") {
+		match := readOutputLine.FindStringSubmatch(line)
+		if match == nil || match[1] != strconv.Itoa(next) {
+			break
+		}
+		b.WriteString(match[2])
+		b.WriteString("\n")
+		next++
+	}
+	return b.String(), next > 1
+}
+
+// fileChange is what a session's successful edits did to one file
+type fileChange struct {
+	path      string // relative to the project when inside it
+	base      string // content before the first edit
+	baseKnown bool
+	final     *string // content after the last edit, when known
+	created   bool
+	edits     int
+	hunks     []string // hunks of every edit, in order
+	added     int
+	removed   int
+}
+
+// attachDiffs sets the diff of every Edit, MultiEdit and Write call. File
+// content is followed through the session, from full Read results, earlier
+// writes and edits, and the original content Claude Code records, so that
+// diffs have the file's line numbers whenever it is known. The changes of
+// successful calls are returned per file, in the order files were first
+// edited.
+func attachDiffs(messages []models.ConversationMessage, origins map[string]editOrigin, projectPath string) []*fileChange {
+	var changes []*fileChange
+	changeOf := make(map[string]*fileChange)
+
+	failed := make(map[string]bool)
+	for _, msg := range messages {
+		for _, result := range msg.ToolResults {
+			failed[result.ToolUseID] = result.IsError
+		}
+	}
+
+	known := make(map[string]string)
+	reads := make(map[string]string)
+
+	for i := range messages {
+		msg := &messages[i]
+
+		for _, result := range msg.ToolResults {
+			path, ok := reads[result.ToolUseID]
+			if !ok || result.IsError {
+				continue
+			}
+			if content, ok := parseReadOutput(result.Content); ok {
+				known[path] = content
+			}
+		}
+
+		for j := range msg.ToolCalls {
+			call := &msg.ToolCalls[j]
+			path := toolFilePath(*call)
+			if path == "" {
+				continue
+			}
+
+			origin, hasOrigin := origins[call.ID]
+			if hasOrigin && origin.content != nil {
+				known[path] = *origin.content
+			}
+
+			switch call.Name {
+			case "Read":
+				_, hasOffset := call.Input["offset"]
+				_, hasLimit := call.Input["limit"]
+				if !hasOffset && !hasLimit {
+					reads[call.ID] = path
+				}
+			case "Edit", "MultiEdit", "Write":
+				before, isKnown := known[path]
+				created := hasOrigin && origin.created
+				if created {
+					before, isKnown = "", true
+				}
+
+				after, diff := editDiff(*call, before, isKnown)
+				diff.Path = relativeTo(path, projectPath)
+				diff.Created = created
+				hunks := diff.Patch
+				diff.Patch = diffHeader(diff.Path, created) + hunks
+				call.Diff = diff
+
+				if failed[call.ID] {
+					continue
+				}
+				if after != nil {
+					known[path] = *after
+				} else {
+					delete(known, path)
+				}
+
+				change, ok := changeOf[path]
+				if !ok {
+					change = &fileChange{path: diff.Path, base: before, baseKnown: isKnown, created: created}
+					changeOf[path] = change
+					changes = append(changes, change)
+				}
+				change.final = after
+				change.edits++
+				change.hunks = append(change.hunks, hunks)
+				change.added += diff.Added
+				change.removed += diff.Removed
+			}
+		}
+	}
+
+	return changes
+}
+
+// editDiff applies an edit tool call to the file content before it, when
+// known, and returns the content after it, if known, with the diff
+func editDiff(call models.ToolCall, before string, isKnown bool) (*string, *models.FileDiff) {
+	diff := &models.FileDiff{}
+
+	if call.Name == "Write" {
+		content, _ := call.Input["content"].(string)
+		diff.Patch, diff.Added, diff.Removed = unifiedHunks(diffLines(splitLines(before), splitLines(content)), 1)
+		diff.Snippet = !isKnown
+		return &content, diff
+	}
+
+	edits := fileEdits(call)
+	if isKnown {
+		after := before
+		applied := true
+		for _, edit := range edits {
+			if !strings.Contains(after, edit.old) {
+				applied = false
+				break
+			}
+			if edit.all {
+				after = strings.ReplaceAll(after, edit.old, edit.new)
+			} else {
+				after = strings.Replace(after, edit.old, edit.new, 1)
+			}
+		}
+		if applied {
+			diff.Patch, diff.Added, diff.Removed = unifiedHunks(diffLines(splitLines(before), splitLines(after)), 1)
+			return &after, diff
+		}
+	}
+
+	// Only the replaced text is known
+	diff.Snippet = true
+	var patch strings.Builder
+	for _, edit := range edits {
+		hunks, added, removed := unifiedHunks(diffLines(splitLines(withNewline(edit.old)), splitLines(withNewline(edit.new))), 1)
+		patch.WriteString(hunks)
+		diff.Added += added
+		diff.Removed += removed
+	}
+	diff.Patch = patch.String()
+	return nil, diff
+}
+
+type fileEdit struct {
+	old, new string
+	all      bool
+}
+
+// fileEdits returns the replacements of an Edit or MultiEdit call
+func fileEdits(call models.ToolCall) []fileEdit {
+	parse := func(input map[string]interface{}) fileEdit {
+		var edit fileEdit
+		edit.old, _ = input["old_string"].(string)
+		edit.new, _ = input["new_string"].(string)
+		edit.all, _ = input["replace_all"].(bool)
+		return edit
+	}
+
+	if call.Name == "Edit" {
+		return []fileEdit{parse(call.Input)}
+	}
+	var edits []fileEdit
+	items, _ := call.Input["edits"].([]interface{})
+	for _, item := range items {
+		if input, ok := item.(map[string]interface{}); ok {
+			edits = append(edits, parse(input))
+		}
+	}
+	return edits
+}
+
+func withNewline(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
+
+// diffHeader returns the ---/+++ lines of a file's diff in git's a/ b/ form
+func diffHeader(path string, created bool) string {
+	path = strings.TrimPrefix(path, "/")
+	if created {
+		return "--- /dev/null\n+++ b/" + path + "\n"
+	}
+	return "--- a/" + path + "\n+++ b/" + path + "\n"
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// GetProjectFiles returns the files read or edited in a project's sessions,
+// most edited first. When query is set, only paths containing it are kept.
+func (s *SessionService) GetProjectFiles(encodedPath, query string) ([]models.FileActivity, error) {
+	index, err := s.fileIndex(encodedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	files := []models.FileActivity{}
+	for _, file := range index {
+		if query != "" && !strings.Contains(strings.ToLower(file.Path), query) {
+			continue
+		}
+		files = append(files, *file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Edits != files[j].Edits {
+			return files[i].Edits > files[j].Edits
+		}
+		if files[i].Reads != files[j].Reads {
+			return files[i].Reads > files[j].Reads
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// fileIndex maps every file path touched by Read, Edit, MultiEdit, Write or
+// notebook tool calls in a project to the calls touching it
+func (s *SessionService) fileIndex(encodedPath string) (map[string]*models.FileActivity, error) {
+	index := make(map[string]*models.FileActivity)
+	_, err := s.getProjectSessions(encodedPath, func(info models.SessionInfo, session models.Session) {
+		title := info.Title
+		if title == "" {
+			title = info.FirstMessage
+		}
+
+		touched := make(map[string]bool)
+		for _, msg := range session.Messages {
+			for _, call := range msg.ToolCalls {
+				isRead := slices.Contains(readTools, call.Name)
+				isEdit := slices.Contains(editTools, call.Name)
+				path := toolFilePath(call)
+				if path == "" || (!isRead && !isEdit) {
+					continue
+				}
+				path = relativeTo(path, projectRoot(session))
+
+				file, ok := index[path]
+				if !ok {
+					file = &models.FileActivity{Path: path}
+					index[path] = file
+				}
+				if isEdit {
+					file.Edits++
+				} else {
+					file.Reads++
+				}
+				if !touched[path] {
+					touched[path] = true
+					file.Sessions++
+				}
+				if msg.Timestamp.After(file.LastTouched) {
+					file.LastTouched = msg.Timestamp
+				}
+				file.Touches = append(file.Touches, models.FileTouch{
+					SessionID:    info.ID,
+					SessionTitle: title,
+					MessageUUID:  msg.UUID,
+					Tool:         call.Name,
+					Edit:         isEdit,
+					Timestamp:    msg.Timestamp,
+				})
+			}
+		}
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("project %s: %w", encodedPath, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range index {
+		sort.SliceStable(file.Touches, func(i, j int) bool {
+			return file.Touches[i].Timestamp.After(file.Touches[j].Timestamp)
+		})
+	}
+
+	return index, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetProjectFiles(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": forkedSession,
+		"-tmp-app/s2": jsonl(
+			`{"type":"user","uuid":"e1","timestamp":"2026-01-02T10:00:00Z","cwd":"/tmp/app","message":{"role":"user","content":"Edit a"}}`,
+			`{"type":"assistant","uuid":"e2","parentUuid":"e1","timestamp":"2026-01-02T10:00:01Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t9","name":"Edit","input":{"file_path":"/tmp/app/a.go","old_string":"a","new_string":"b"}}]}}`,
+		),
+	})
+
+	tests := []struct {
+		query string
+		want  []string // paths, most edited first
+	}{
+		{"", []string{"a.go", "b.go"}},
+		{"B.GO", []string{"b.go"}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		files, err := s.GetProjectFiles("-tmp-app", tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.Path)
+		}
+		if !slices.Equal(paths, tt.want) {
+			t.Errorf("GetProjectFiles(%q) = %v, want %v", tt.query, paths, tt.want)
+		}
+	}
+
+	files, err := s.GetProjectFiles("-tmp-app", "a.go")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("GetProjectFiles(a.go) = %+v, %v", files, err)
+	}
+	a := files[0]
+	if a.Reads != 1 || a.Edits != 1 || a.Sessions != 2 {
+		t.Errorf("a.go = %d reads, %d edits in %d sessions; want 1, 1, 2", a.Reads, a.Edits, a.Sessions)
+	}
+	if len(a.Touches) != 2 || a.Touches[0].SessionID != "s2" || !a.Touches[0].Edit {
+		t.Errorf("touches = %+v, want the edit in s2 first", a.Touches)
+	}
+
+	if _, err := s.GetProjectFiles("-tmp-gone", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetProjectFiles() of a missing project error = %v, want ErrNotFound", err)
+	}
+}
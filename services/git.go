@@ -0,0 +1,300 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// commitGrace is how long after a session's last message a commit is still
+// attributed to it, for commits made by hand once the agent has finished
+const commitGrace = 10 * time.Minute
+
+// maxCommits bounds the commits read from a repository's log
+const maxCommits = 1000
+
+const gitTimeout = 10 * time.Second
+
+// ErrNotRepo is returned when a project's directory is not a git repository
+var ErrNotRepo = errors.New("not a git repository")
+
+// commitOutput matches the summary line git commit prints, such as
+// "[main 1a2b3c4] Fix the parser" or "[main (root-commit) 1a2b3c4] Init"
+var commitOutput = regexp.MustCompile(`(?m)^\[[^\]\n]*?([0-9a-f]{7,40})\] `)
+
+// sessionCommits is what a session tells about the commits made in it
+type sessionCommits struct {
+	info   models.SessionInfo
+	repo   string   // working directory the session ran in
+	hashes []string // abbreviated hashes printed by git commit
+}
+
+// GetProjectCommits reads the git log of a project's directory since its
+// first session and attributes each commit to the sessions that made it,
+// newest first. A commit whose hash shows in the output of a git commit run
+// in a session belongs to that session; any other commit belongs to the
+// sessions running when it was authored.
+func (s *SessionService) GetProjectCommits(encodedPath string) ([]models.Commit, error) {
+	archived, _, _ := s.loadArchivedData()
+	sessions, err := s.commitSessions(encodedPath, func(sessionID string) bool {
+		return !archived[sessionID]
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return []models.Commit{}, nil
+	}
+
+	since := sessions[0].info.StartTime
+	for _, session := range sessions {
+		if session.info.StartTime.Before(since) {
+			since = session.info.StartTime
+		}
+	}
+
+	commits, err := gitLog(s.commitRepo(encodedPath, sessions), since)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range commits {
+		commits[i].Sessions = attributeCommit(commits[i], sessions)
+	}
+	return commits, nil
+}
+
+// GetSessionCommits returns the commits attributed to a session. It only
+// reads the git log of the session's working directory since the session
+// started, and only parses the sessions running at the same time, which may
+// have printed the hash of a commit made then. It is empty when the directory
+// is not a git repository.
+func (s *SessionService) GetSessionCommits(encodedPath, sessionID string) ([]models.Commit, error) {
+	session, err := s.parseSessionHeaders(encodedPath, sessionID, nil)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %s: %w", sessionID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(session.Messages) == 0 {
+		return []models.Commit{}, nil
+	}
+	start, end := session.StartTime, session.EndTime.Add(commitGrace)
+
+	archived, _, _ := s.loadArchivedData()
+	candidates, err := s.commitSessions(encodedPath, func(otherID string) bool {
+		if otherID == sessionID {
+			return true
+		}
+		// A session whose file was last written before this one started
+		// ended before it too
+		stat, err := s.source.Stat(encodedPath, otherID)
+		return err == nil && !archived[otherID] && !stat.ModTime().Before(start)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var target []sessionCommits
+	sessions := []sessionCommits{}
+	for _, other := range candidates {
+		if other.info.ID == sessionID {
+			target = append(target, other)
+		}
+		if other.info.StartTime.After(end) || other.info.EndTime.Add(commitGrace).Before(start) {
+			continue
+		}
+		sessions = append(sessions, other)
+	}
+
+	commits, err := gitLog(s.commitRepo(encodedPath, target), start)
+	if errors.Is(err, ErrNotRepo) {
+		return []models.Commit{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found := []models.Commit{}
+	for _, commit := range commits {
+		commit.Sessions = attributeCommit(commit, sessions)
+		for _, ref := range commit.Sessions {
+			if ref.SessionID == sessionID {
+				found = append(found, commit)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// commitSessions parses the sessions of a project whose ID passes keep for
+// the commits they made, in file order. Subagent sessions and files without
+// messages are left out.
+func (s *SessionService) commitSessions(encodedPath string, keep func(sessionID string) bool) ([]sessionCommits, error) {
+	sessionIDs, err := s.source.Sessions(encodedPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("project %s: %w", encodedPath, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := s.summaryIndex(encodedPath)
+	annotations := s.loadSessionAnnotations()
+
+	var sessions []sessionCommits
+	for _, sessionID := range sessionIDs {
+		if strings.HasPrefix(sessionID, "agent-") || !keep(sessionID) {
+			continue
+		}
+		session, err := s.parseSessionHeaders(encodedPath, sessionID, summaries)
+		if err != nil || len(session.Messages) == 0 {
+			continue
+		}
+		sessions = append(sessions, newSessionCommits(encodedPath, sessionID, session, annotations[sessionID]))
+	}
+	return sessions, nil
+}
+
+// commitRepo returns the repository the commits of sessions are read from,
+// the working directory of the latest of them or else the project's
+// directory
+func (s *SessionService) commitRepo(encodedPath string, sessions []sessionCommits) string {
+	repo := s.decodeProjectPath(encodedPath)
+	var latest time.Time
+	for _, session := range sessions {
+		if session.repo != "" && session.info.StartTime.After(latest) {
+			repo, latest = session.repo, session.info.StartTime
+		}
+	}
+	return repo
+}
+
+// newSessionCommits returns what a parsed session tells about its commits
+func newSessionCommits(encodedPath, sessionID string, session models.Session, annotation models.SessionAnnotation) sessionCommits {
+	info := newSessionInfo(encodedPath, sessionID, session)
+	applyAnnotation(&info, annotation)
+	var repo string
+	if cwds := session.Metadata.Cwds; len(cwds) > 0 {
+		repo = cwds[0]
+	}
+	return sessionCommits{info: info, repo: repo, hashes: commitHashes(session)}
+}
+
+// commitHashes returns the hashes git commit printed in a session
+func commitHashes(session models.Session) []string {
+	var hashes []string
+	for _, msg := range session.Messages {
+		for _, result := range msg.ToolResults {
+			for _, match := range commitOutput.FindAllStringSubmatch(result.Content, -1) {
+				hashes = append(hashes, match[1])
+			}
+		}
+	}
+	return hashes
+}
+
+// attributeCommit returns the sessions a commit was made in
+func attributeCommit(commit models.Commit, sessions []sessionCommits) []models.CommitSession {
+	ref := func(session sessionCommits, matched string) models.CommitSession {
+		return models.CommitSession{
+			SessionID: session.info.ID,
+			Title:     sessionTitle(session.info),
+			Branch:    session.info.GitBranch,
+			Matched:   matched,
+		}
+	}
+
+	attributed := []models.CommitSession{}
+	for _, session := range sessions {
+		for _, hash := range session.hashes {
+			if strings.HasPrefix(commit.Hash, hash) {
+				attributed = append(attributed, ref(session, "output"))
+				break
+			}
+		}
+	}
+	if len(attributed) > 0 {
+		return attributed
+	}
+
+	for _, session := range sessions {
+		start, end := session.info.StartTime, session.info.EndTime.Add(commitGrace)
+		if !commit.Time.Before(start) && !commit.Time.After(end) {
+			attributed = append(attributed, ref(session, "time"))
+		}
+	}
+	return attributed
+}
+
+func sessionTitle(info models.SessionInfo) string {
+	if info.Title != "" {
+		return info.Title
+	}
+	return info.FirstMessage
+}
+
+// gitLog returns the commits on any branch of the repository at dir authored
+// since a time, newest first
+func gitLog(dir string, since time.Time) ([]models.Commit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	// Commit dates are never before author dates, so filtering on them with
+	// --since keeps every commit authored since then
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "log", "--all",
+		"--since=@"+strconv.FormatInt(since.Unix(), 10),
+		"--max-count="+strconv.Itoa(maxCommits),
+		"--format=%H%x1f%h%x1f%an%x1f%at%x1f%D%x1f%s")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// The directory may be gone, not a repository, or git not installed
+		if msg := stderr.String(); strings.Contains(msg, "not a git repository") || strings.Contains(msg, "cannot change to") || errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", dir, ErrNotRepo)
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("git log in %s: %w", dir, ctx.Err())
+		}
+		return nil, fmt.Errorf("git log in %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+
+	commits := []models.Commit{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		unix, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, models.Commit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Time:      time.Unix(unix, 0),
+			Refs:      fields[4],
+			Subject:   fields[5],
+		})
+	}
+
+	// --all lists commits in commit date order; show them by author date
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Time.After(commits[j].Time)
+	})
+	return commits, nil
+}
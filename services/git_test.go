@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCommitsOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": strings.ReplaceAll(forkedSession, "/tmp/app", dir),
+	})
+
+	tests := []struct {
+		name     string
+		commits  func() (int, error)
+		wantErr  error
+		wantNone bool
+	}{
+		{
+			name:    "project",
+			commits: func() (int, error) { c, err := s.GetProjectCommits("-tmp-app"); return len(c), err },
+			wantErr: ErrNotRepo,
+		},
+		{
+			name:     "session",
+			commits:  func() (int, error) { c, err := s.GetSessionCommits("-tmp-app", "s1"); return len(c), err },
+			wantNone: true,
+		},
+		{
+			name:    "missing project",
+			commits: func() (int, error) { c, err := s.GetProjectCommits("-tmp-gone"); return len(c), err },
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "missing session",
+			commits: func() (int, error) { c, err := s.GetSessionCommits("-tmp-app", "gone"); return len(c), err },
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.commits()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrNotRepo) && errors.Is(err, ErrNotFound) {
+					t.Error("a directory outside git is reported as not found")
+				}
+				return
+			}
+			if err != nil || (tt.wantNone && n != 0) {
+				t.Errorf("got %d commits, %v; want none", n, err)
+			}
+		})
+	}
+}
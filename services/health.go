@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// GetDataHealth reads every session file under ~/.claude/projects, archived
+// sessions and agent transcripts included, and reports the files, lines and
+// types the viewer could not make sense of. It shows what the viewer misses
+// when Claude Code changes its format.
+func (s *SessionService) GetDataHealth() (models.DataHealth, error) {
+	encodedPaths, err := s.source.Projects()
+	if err != nil {
+		return models.DataHealth{}, fmt.Errorf("failed to read projects directory: %w", err)
+	}
+
+	health := models.DataHealth{
+		GeneratedAt:  time.Now(),
+		Files:        []models.FileHealth{},
+		CorruptFiles: s.corruptDataFiles(),
+	}
+	var types, blocks unknownTotals
+
+	for _, encodedPath := range encodedPaths {
+		health.Projects++
+
+		sessionIDs, err := s.source.Sessions(encodedPath)
+		if err != nil {
+			health.FailedFiles++
+			health.Files = append(health.Files, models.FileHealth{Path: encodedPath, EncodedPath: encodedPath, Error: err.Error()})
+			continue
+		}
+
+		for _, sessionID := range sessionIDs {
+			fileHealth := models.FileHealth{
+				Path:        encodedPath + "/" + sessionID + ".jsonl",
+				EncodedPath: encodedPath,
+			}
+			if !strings.HasPrefix(sessionID, "agent-") {
+				fileHealth.SessionID = sessionID
+			}
+			health.SessionFiles++
+
+			session, err := s.parseSessionHeaders(encodedPath, sessionID, nil)
+			if err != nil {
+				health.FailedFiles++
+				fileHealth.Error = err.Error()
+				health.Files = append(health.Files, fileHealth)
+				continue
+			}
+
+			diagnostics := session.Diagnostics
+			if diagnostics.Empty() {
+				continue
+			}
+			health.SkippedLines += diagnostics.SkippedLines
+			types.add(diagnostics.UnknownTypes, fileHealth.Path)
+			blocks.add(diagnostics.UnknownBlocks, fileHealth.Path)
+			fileHealth.Diagnostics = &diagnostics
+			health.Files = append(health.Files, fileHealth)
+		}
+	}
+
+	health.UnknownTypes = types.sorted()
+	health.UnknownBlocks = blocks.sorted()
+	return health, nil
+}
+
+// unknownTotals adds up the unknown types of many files
+type unknownTotals struct {
+	totals []models.UnknownTypeTotal
+	index  map[string]int
+}
+
+func (t *unknownTotals) add(unknown []models.UnknownType, path string) {
+	if t.index == nil {
+		t.index = make(map[string]int)
+	}
+	for _, u := range unknown {
+		i, ok := t.index[u.Type]
+		if !ok {
+			i = len(t.totals)
+			t.index[u.Type] = i
+			t.totals = append(t.totals, models.UnknownTypeTotal{
+				Type:    u.Type,
+				Example: fmt.Sprintf("%s:%d", path, u.FirstLine),
+			})
+		}
+		t.totals[i].Count += u.Count
+		t.totals[i].Files++
+	}
+}
+
+// sorted returns the totals, most frequent first
+func (t *unknownTotals) sorted() []models.UnknownTypeTotal {
+	totals := append([]models.UnknownTypeTotal{}, t.totals...)
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Count > totals[j].Count
+	})
+	return totals
+}
+
+// corruptDataFiles lists the viewer's state files that were unreadable and
+// moved aside by the store
+func (s *SessionService) corruptDataFiles() []string {
+	matches, _ := filepath.Glob(filepath.Join(s.dataDir, "*.corrupt-*"))
+	files := []string{}
+	for _, match := range matches {
+		files = append(files, filepath.Base(match))
+	}
+	return files
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGetDataHealth(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": deploySession,
+		"-tmp-app/s2": jsonl(
+			`{"type":"user","uuid":"p1","timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":"Hi"}}`,
+			`{"type":"future-record","uuid":"f1"}`,
+			`{"type":"future-record","uuid":"f2"}`,
+			`{"type":"assistant","uuid":"a1","parentUuid":"p1","timestamp":"2026-01-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"widget"},{"type":"text","text":"Hello"}]}}`,
+		),
+		"-tmp-web/s3":      forkedSession,
+		"-tmp-web/agent-x": jsonl(`{"type":"user"`, `{"type":"future-record"}`),
+	})
+	if err := os.WriteFile(filepath.Join(s.dataDir, "archive.json.corrupt-1"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := s.GetDataHealth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Projects != 2 || health.SessionFiles != 4 || health.FailedFiles != 0 || health.SkippedLines != 2 {
+		t.Errorf("got %d projects, %d files, %d failed, %d skipped lines; want 2, 4, 0, 2",
+			health.Projects, health.SessionFiles, health.FailedFiles, health.SkippedLines)
+	}
+	if !slices.Equal(health.CorruptFiles, []string{"archive.json.corrupt-1"}) {
+		t.Errorf("corrupt files = %v", health.CorruptFiles)
+	}
+
+	// Only files with problems are listed
+	tests := []struct {
+		path          string
+		sessionID     string
+		skipped       int
+		unknownTypes  int
+		unknownBlocks int
+	}{
+		{"-tmp-app/s2.jsonl", "s2", 0, 1, 1},
+		{"-tmp-web/agent-x.jsonl", "", 1, 1, 0},
+		{"-tmp-web/s3.jsonl", "s3", 1, 0, 0},
+	}
+	if len(health.Files) != len(tests) {
+		t.Fatalf("got files %+v, want %d", health.Files, len(tests))
+	}
+	for i, tt := range tests {
+		file := health.Files[i]
+		d := file.Diagnostics
+		if file.Path != tt.path || file.SessionID != tt.sessionID || d == nil {
+			t.Errorf("file %d = %+v, want %s", i, file, tt.path)
+			continue
+		}
+		if d.SkippedLines != tt.skipped || len(d.UnknownTypes) != tt.unknownTypes || len(d.UnknownBlocks) != tt.unknownBlocks {
+			t.Errorf("%s: %d skipped, %d unknown types, %d unknown blocks; want %d, %d, %d",
+				tt.path, d.SkippedLines, len(d.UnknownTypes), len(d.UnknownBlocks), tt.skipped, tt.unknownTypes, tt.unknownBlocks)
+		}
+	}
+
+	if len(health.UnknownTypes) != 1 {
+		t.Fatalf("unknown types = %+v, want future-record only", health.UnknownTypes)
+	}
+	if total := health.UnknownTypes[0]; total.Type != "future-record" || total.Count != 3 || total.Files != 2 || total.Example != "-tmp-app/s2.jsonl:2" {
+		t.Errorf("future-record total = %+v, want 3 in 2 files first seen at -tmp-app/s2.jsonl:2", total)
+	}
+	if len(health.UnknownBlocks) != 1 || health.UnknownBlocks[0].Type != "widget" {
+		t.Errorf("unknown blocks = %+v, want widget", health.UnknownBlocks)
+	}
+}
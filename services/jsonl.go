@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// jsonlBufferSize is the read buffer of forEachLine. Longer lines are still
+// read whole, into a buffer of their own.
+const jsonlBufferSize = 64 * 1024
+
+// parseMode selects how much of a session file is worked out
+type parseMode int
+
+const (
+	// parseFull also stores images and works out file diffs and todo lists
+	parseFull parseMode = iota
+	// parseHeaders keeps messages with their text, kinds, tool calls and
+	// times, which is all that listing, searching and statistics need
+	parseHeaders
+)
+
+// forEachLine streams the lines of a JSONL file to fn, however long they are.
+// Lines are numbered from 1; blank lines are skipped. The line passed to fn
+// is only valid until it returns. Reading stops at the first error fn
+// returns, which is returned as is.
+func forEachLine(r io.Reader, fn func(number int, line []byte) error) error {
+	reader := bufio.NewReaderSize(r, jsonlBufferSize)
+
+	for number := 1; ; number++ {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long := append([]byte(nil), line...)
+			for err == bufio.ErrBufferFull {
+				line, err = reader.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := fn(number, line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// decodeLine decodes a JSONL line. With parseHeaders it leaves out what only
+// a full parse uses: tool use results, which repeat the content of the files
+// read and edited, todo lists and thinking metadata.
+func decodeLine(line []byte, mode parseMode) (models.JSONLMessage, error) {
+	if mode == parseFull {
+		var msg models.JSONLMessage
+		err := json.Unmarshal(line, &msg)
+		return msg, err
+	}
+
+	// Fields not in the struct are skipped by the decoder without being copied
+	var header struct {
+		Type             string                 `json:"type"`
+		ParentUUID       *string                `json:"parentUuid"`
+		IsSidechain      bool                   `json:"isSidechain"`
+		UserType         string                 `json:"userType"`
+		CWD              string                 `json:"cwd"`
+		SessionID        string                 `json:"sessionId"`
+		Version          string                 `json:"version"`
+		GitBranch        string                 `json:"gitBranch"`
+		Message          *models.MessageContent `json:"message"`
+		UUID             string                 `json:"uuid"`
+		Timestamp        time.Time              `json:"timestamp"`
+		RequestID        string                 `json:"requestId"`
+		IsMeta           bool                   `json:"isMeta"`
+		IsCompactSummary bool                   `json:"isCompactSummary"`
+		Summary          string                 `json:"summary"`
+		LeafUUID         string                 `json:"leafUuid"`
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return models.JSONLMessage{}, err
+	}
+	return models.JSONLMessage{
+		Type:             header.Type,
+		ParentUUID:       header.ParentUUID,
+		IsSidechain:      header.IsSidechain,
+		UserType:         header.UserType,
+		CWD:              header.CWD,
+		SessionID:        header.SessionID,
+		Version:          header.Version,
+		GitBranch:        header.GitBranch,
+		Message:          header.Message,
+		UUID:             header.UUID,
+		Timestamp:        header.Timestamp,
+		RequestID:        header.RequestID,
+		IsMeta:           header.IsMeta,
+		IsCompactSummary: header.IsCompactSummary,
+		Summary:          header.Summary,
+		LeafUUID:         header.LeafUUID,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForEachLineReadsLongLines(t *testing.T) {
+	long := `{"text":"` + strings.Repeat("x", 3*jsonlBufferSize) + `"}`
+	input := "{}\n\n" + long + "\n  \n{\"last\":true}"
+
+	var numbers []int
+	var lengths []int
+	err := forEachLine(strings.NewReader(input), func(number int, line []byte) error {
+		numbers = append(numbers, number)
+		lengths = append(lengths, len(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 3, 5}) {
+		t.Errorf("line numbers = %v, want [1 3 5]", numbers)
+	}
+	if len(lengths) != 3 || lengths[1] != len(long) {
+		t.Errorf("line lengths = %v, want the long line whole", lengths)
+	}
+}
+
+func TestDecodeLineHeaders(t *testing.T) {
+	lines := append(strings.Split(strings.TrimSpace(forkedSession), "\n")[:8],
+		`{"type":"user","uuid":"i1","message":{"role":"user","content":[{"type":"text","text":""},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}}]}}`,
+		`{"type":"user","uuid":"r3","toolUseResult":{"filePath":"/tmp/app/a.go","oldString":"a","newString":"b"},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","is_error":true,"content":[{"type":"text","text":"failed"},{"type":"image","source":{"data":"AAAA"}}]}]}}`,
+		`{"type":"user","uuid":"r4","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t4","content":null}]}}`,
+		`{"type":"user","uuid":"o1","todos":[{"content":"Fix","status":"pending"}],"thinkingMetadata":{"level":"high"},"message":{"role":"user","content":["plain",3,{"type":"text","text":5},{"type":"future","data":{"x":1}}]}}`,
+		`{"type":"assistant","uuid":"a9","message":{"role":"assistant","model":"claude","id":"msg_1","usage":{"input_tokens":3},"content":[{"type":"thinking","thinking":"hmm","signature":"sig"},{"type":"tool_use","id":"t5","name":"Bash","input":{"command":"go test"}}]}}`,
+		`{"type":"user","uuid":"n1","message":{"role":"user","content":null}}`,
+		`{"type":"summary","summary":"Fixing the bug","leafUuid":"a2"}`,
+	)
+
+	for _, line := range lines {
+		full, err := decodeLine([]byte(line), parseFull)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		header, err := decodeLine([]byte(line), parseHeaders)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+
+		// Headers are the full line without what only a full parse uses
+		full.ToolUseResult = nil
+		full.Todos = nil
+		full.ThinkingMetadata = nil
+		if !reflect.DeepEqual(header, full) {
+			t.Errorf("%s:\nheaders %+v\nfull    %+v", line, header, full)
+		}
+	}
+}
+
+func TestDecodeLineErrors(t *testing.T) {
+	for _, line := range []string{`not json`, `{"type":"user","message":{"content":[{"type":"text"}`, `{"toolUseResult":}`} {
+		for _, mode := range []parseMode{parseFull, parseHeaders} {
+			if _, err := decodeLine([]byte(line), mode); err == nil {
+				t.Errorf("decodeLine(%q, %d) succeeded", line, mode)
+			}
+		}
+	}
+}
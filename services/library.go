@@ -0,0 +1,203 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// LibraryData represents the structure of the prompt library JSON file
+type LibraryData struct {
+	Items []models.LibraryItem `json:"items"`
+}
+
+// ListLibrary returns the saved prompts matching filter, newest first
+func (s *SessionService) ListLibrary(filter models.LibraryFilter) ([]models.LibraryItem, error) {
+	data, err := s.library.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(filter.Query)
+	tag := normalizeTag(filter.Tag)
+
+	items := []models.LibraryItem{}
+	for _, item := range data.Items {
+		if filter.StarredOnly && !item.Starred {
+			continue
+		}
+		if tag != "" && !slices.Contains(item.Tags, tag) {
+			continue
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(item.Title), query) &&
+			!strings.Contains(strings.ToLower(item.Content), query) &&
+			!strings.Contains(strings.ToLower(item.Notes), query) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	return items, nil
+}
+
+// LibraryTags returns every tag in the library with the number of prompts using it
+func (s *SessionService) LibraryTags() (map[string]int, error) {
+	data, err := s.library.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]int)
+	for _, item := range data.Items {
+		for _, tag := range item.Tags {
+			tags[tag]++
+		}
+	}
+	return tags, nil
+}
+
+// GetLibraryItem returns a saved prompt by its ID
+func (s *SessionService) GetLibraryItem(id string) (models.LibraryItem, error) {
+	data, err := s.library.Read()
+	if err != nil {
+		return models.LibraryItem{}, err
+	}
+	for _, item := range data.Items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return models.LibraryItem{}, fmt.Errorf("library item %s: %w", id, ErrNotFound)
+}
+
+// AddToLibrary saves a prompt to the library. When the item points at a
+// prompt in a session, its content is taken from the session.
+func (s *SessionService) AddToLibrary(item models.LibraryItem) (models.LibraryItem, error) {
+	if item.PromptUUID != "" {
+		turn, err := s.GetTurn(item.EncodedPath, item.SessionID, item.PromptUUID)
+		if err != nil {
+			return models.LibraryItem{}, err
+		}
+		item.Content = PromptText(turn.Prompt)
+	}
+	if strings.TrimSpace(item.Content) == "" {
+		return models.LibraryItem{}, fmt.Errorf("prompt content is empty: %w", ErrInvalid)
+	}
+
+	err := s.library.Update(func(data *LibraryData) error {
+		for _, existing := range data.Items {
+			if item.PromptUUID != "" && existing.PromptUUID == item.PromptUUID {
+				item = existing
+				return fmt.Errorf("prompt %s is already in the library: %w", existing.PromptUUID, ErrConflict)
+			}
+		}
+
+		now := time.Now()
+		item.ID = newID()
+		item.Tags = normalizeTags(item.Tags)
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		if item.Title == "" {
+			item.Title = defaultTitle(item.Content)
+		}
+
+		data.Items = append(data.Items, item)
+		return nil
+	})
+	if errors.Is(err, ErrConflict) {
+		return item, err
+	}
+	if err != nil {
+		return models.LibraryItem{}, err
+	}
+	return item, nil
+}
+
+// UpdateLibraryItem changes the title, notes, tags or star of a saved prompt
+func (s *SessionService) UpdateLibraryItem(id string, update models.LibraryUpdate) (models.LibraryItem, error) {
+	var updated models.LibraryItem
+	err := s.library.Update(func(data *LibraryData) error {
+		for i := range data.Items {
+			item := &data.Items[i]
+			if item.ID != id {
+				continue
+			}
+
+			if update.Title != nil {
+				item.Title = strings.TrimSpace(*update.Title)
+			}
+			if update.Notes != nil {
+				item.Notes = *update.Notes
+			}
+			if update.Tags != nil {
+				item.Tags = normalizeTags(*update.Tags)
+			}
+			if update.Starred != nil {
+				item.Starred = *update.Starred
+			}
+			item.UpdatedAt = time.Now()
+
+			updated = *item
+			return nil
+		}
+		return fmt.Errorf("library item %s: %w", id, ErrNotFound)
+	})
+	if err != nil {
+		return models.LibraryItem{}, err
+	}
+	return updated, nil
+}
+
+// DeleteLibraryItem removes a prompt from the library
+func (s *SessionService) DeleteLibraryItem(id string) error {
+	return s.library.Update(func(data *LibraryData) error {
+		for i, item := range data.Items {
+			if item.ID == id {
+				data.Items = slices.Delete(data.Items, i, i+1)
+				return nil
+			}
+		}
+		return fmt.Errorf("library item %s: %w", id, ErrNotFound)
+	})
+}
+
+// normalizeTags lowercases, trims and deduplicates tags
+func normalizeTags(tags []string) []string {
+	result := []string{}
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+}
+
+// defaultTitle derives a title from the first line of a prompt
+func defaultTitle(content string) string {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(content), "\n", 2)[0])
+	return Truncate(line, 60)
+}
+
+// newID returns a random identifier for user-created records
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestLibrary(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	saved, err := s.AddToLibrary(models.LibraryItem{EncodedPath: "-tmp-app", SessionID: "s1", PromptUUID: "p1", Tags: []string{" #Debug ", "debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Content != "Fix the bug" || saved.Title != "Fix the bug" {
+		t.Errorf("saved %q titled %q, want the prompt from the session", saved.Content, saved.Title)
+	}
+	if len(saved.Tags) != 1 || saved.Tags[0] != "debug" {
+		t.Errorf("tags = %v, want [debug]", saved.Tags)
+	}
+	if _, err := s.AddToLibrary(models.LibraryItem{EncodedPath: "-tmp-app", SessionID: "s1", PromptUUID: "p1"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("saving p1 twice error = %v, want ErrConflict", err)
+	}
+	if _, err := s.AddToLibrary(models.LibraryItem{Content: "  "}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("saving an empty prompt error = %v, want ErrInvalid", err)
+	}
+
+	typed, err := s.AddToLibrary(models.LibraryItem{Content: "Write the release notes\nfor v2", Notes: "monthly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	starred := true
+	if _, err := s.UpdateLibraryItem(typed.ID, models.LibraryUpdate{Starred: &starred}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.LibraryFilter
+		want   []string // titles, newest first
+	}{
+		{"all", models.LibraryFilter{}, []string{"Write the release notes", "Fix the bug"}},
+		{"tag", models.LibraryFilter{Tag: "#DEBUG"}, []string{"Fix the bug"}},
+		{"query in notes", models.LibraryFilter{Query: "Monthly"}, []string{"Write the release notes"}},
+		{"starred", models.LibraryFilter{StarredOnly: true}, []string{"Write the release notes"}},
+		{"no match", models.LibraryFilter{Query: "deploy"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := s.ListLibrary(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := []string{}
+			for _, item := range items {
+				titles = append(titles, item.Title)
+			}
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("titles = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+
+	if err := s.DeleteLibraryItem(saved.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetLibraryItem(saved.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetLibraryItem() after delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteLibraryItem(saved.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting twice error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// syntheticModel is the model Claude Code records on messages it writes itself
+const syntheticModel = "<synthetic>"
+
+// metadataCollector gathers the environment of a session from its lines
+type metadataCollector struct {
+	meta   models.SessionMetadata
+	branch string // latest git branch
+	// usage of each API response by message id. A response is written as one
+	// line per content block, each repeating its usage, so the last one wins.
+	usage   map[string]map[string]interface{}
+	replies []string
+}
+
+func newMetadataCollector() *metadataCollector {
+	return &metadataCollector{usage: make(map[string]map[string]interface{})}
+}
+
+// add records the metadata carried by a line
+func (c *metadataCollector) add(line models.JSONLMessage) {
+	c.meta.Versions = appendNew(c.meta.Versions, line.Version)
+	c.meta.Cwds = appendNew(c.meta.Cwds, line.CWD)
+	c.meta.UserTypes = appendNew(c.meta.UserTypes, line.UserType)
+	c.meta.GitBranches = appendNew(c.meta.GitBranches, line.GitBranch)
+	if line.GitBranch != "" {
+		c.branch = line.GitBranch
+	}
+
+	if line.Message == nil || line.Message.Role != "assistant" {
+		return
+	}
+	if line.Message.Model != syntheticModel {
+		c.meta.Models = appendNew(c.meta.Models, line.Message.Model)
+	}
+	if line.Message.ID != "" && line.Message.Usage != nil {
+		if _, ok := c.usage[line.Message.ID]; !ok {
+			c.replies = append(c.replies, line.Message.ID)
+		}
+		c.usage[line.Message.ID] = line.Message.Usage
+	}
+}
+
+// result returns the metadata of a session running from start to end
+func (c *metadataCollector) result(start, end time.Time) models.SessionMetadata {
+	meta := c.meta
+	if !start.IsZero() {
+		meta.DurationSeconds = int64(end.Sub(start).Seconds())
+	}
+
+	count := func(usage map[string]interface{}, key string) int64 {
+		n, _ := usage[key].(float64)
+		return int64(n)
+	}
+	for _, id := range c.replies {
+		usage := c.usage[id]
+		meta.Tokens.Input += count(usage, "input_tokens")
+		meta.Tokens.Output += count(usage, "output_tokens")
+		meta.Tokens.CacheCreation += count(usage, "cache_creation_input_tokens")
+		meta.Tokens.CacheRead += count(usage, "cache_read_input_tokens")
+	}
+	meta.Tokens.Total = meta.Tokens.Input + meta.Tokens.Output + meta.Tokens.CacheCreation + meta.Tokens.CacheRead
+	meta.Responses = len(c.replies)
+
+	return meta
+}
+
+// appendNew appends value to list unless it is empty or already there
+func appendNew(list []string, value string) []string {
+	if value == "" || slices.Contains(list, value) {
+		return list
+	}
+	return append(list, value)
+}
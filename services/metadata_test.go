@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestSessionMetadata(t *testing.T) {
+	prompt := `{"type":"user","uuid":"p1","parentUuid":null,"timestamp":"2026-01-01T10:00:00Z","cwd":"/tmp/app","version":"1.0.1","gitBranch":"main","userType":"external","message":{"role":"user","content":"Fix the bug"}}`
+	reply := func(uuid, timestamp, id, model, usage string) string {
+		return `{"type":"assistant","uuid":"` + uuid + `","parentUuid":"p1","timestamp":"` + timestamp + `","cwd":"/tmp/app","version":"1.0.2","gitBranch":"fix","message":{"id":"` + id + `","role":"assistant","model":"` + model + `","content":[{"type":"text","text":"ok"}],"usage":` + usage + `}}`
+	}
+
+	tests := []struct {
+		name      string
+		lines     []string
+		models    []string
+		versions  []string
+		branches  []string
+		responses int
+		tokens    models.TokenUsage
+		duration  int64
+	}{
+		{
+			name:     "prompt only",
+			lines:    []string{prompt},
+			versions: []string{"1.0.1"},
+			branches: []string{"main"},
+		},
+		{
+			name: "usage repeated on every block of a response",
+			lines: []string{prompt,
+				reply("a1", "2026-01-01T10:00:05Z", "msg_1", "claude-a", `{"input_tokens":10,"output_tokens":1}`),
+				reply("a2", "2026-01-01T10:00:06Z", "msg_1", "claude-a", `{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}`),
+				reply("a3", "2026-01-01T10:01:00Z", "msg_2", "claude-b", `{"input_tokens":20,"output_tokens":2,"cache_creation_input_tokens":30}`),
+			},
+			models:    []string{"claude-a", "claude-b"},
+			versions:  []string{"1.0.1", "1.0.2"},
+			branches:  []string{"main", "fix"},
+			responses: 2,
+			tokens:    models.TokenUsage{Input: 30, Output: 7, CacheCreation: 30, CacheRead: 100, Total: 167},
+			duration:  60,
+		},
+		{
+			name: "synthetic model left out",
+			lines: []string{prompt,
+				reply("a1", "2026-01-01T10:00:30Z", "msg_1", syntheticModel, `{"input_tokens":0,"output_tokens":0}`),
+			},
+			versions:  []string{"1.0.1", "1.0.2"},
+			branches:  []string{"main", "fix"},
+			responses: 1,
+			duration:  30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, map[string]string{"-tmp-app/s1": jsonl(tt.lines...)})
+			session, err := s.GetSession("-tmp-app", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta := session.Metadata
+
+			if !slices.Equal(meta.Models, tt.models) {
+				t.Errorf("models = %v, want %v", meta.Models, tt.models)
+			}
+			if !slices.Equal(meta.Versions, tt.versions) {
+				t.Errorf("versions = %v, want %v", meta.Versions, tt.versions)
+			}
+			if !slices.Equal(meta.GitBranches, tt.branches) {
+				t.Errorf("branches = %v, want %v", meta.GitBranches, tt.branches)
+			}
+			if !slices.Equal(meta.Cwds, []string{"/tmp/app"}) || !slices.Equal(meta.UserTypes, []string{"external"}) {
+				t.Errorf("cwds = %v, user types = %v", meta.Cwds, meta.UserTypes)
+			}
+			if meta.Responses != tt.responses || meta.Tokens != tt.tokens {
+				t.Errorf("%d responses using %+v, want %d using %+v", meta.Responses, meta.Tokens, tt.responses, tt.tokens)
+			}
+			if meta.DurationSeconds != tt.duration {
+				t.Errorf("duration = %ds, want %ds", meta.DurationSeconds, tt.duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// GetRetention returns the retention rules and the result of their last run
+func (s *SessionService) GetRetention() (models.RetentionData, error) {
+	data, err := s.retention.Read()
+	if data.Rules == nil {
+		data.Rules = []models.RetentionRule{}
+	}
+	return data, err
+}
+
+// SaveRetentionRules replaces the retention rules
+func (s *SessionService) SaveRetentionRules(rules []models.RetentionRule) ([]models.RetentionRule, error) {
+	for i := range rules {
+		rules[i].Name = strings.TrimSpace(rules[i].Name)
+		if rules[i].Name == "" {
+			rules[i].Name = fmt.Sprintf("Rule %d", i+1)
+		}
+		if _, err := compileRule(rules[i]); err != nil {
+			return nil, err
+		}
+	}
+	if rules == nil {
+		rules = []models.RetentionRule{}
+	}
+
+	err := s.retention.Update(func(data *models.RetentionData) error {
+		data.Rules = rules
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// PreviewRetention returns the visible sessions the enabled rules would
+// archive, without archiving them. The stored rules are used when rules is nil.
+func (s *SessionService) PreviewRetention(rules []models.RetentionRule) ([]models.RetentionMatch, error) {
+	if rules == nil {
+		data, err := s.retention.Read()
+		if err != nil {
+			return nil, err
+		}
+		rules = data.Rules
+	}
+	return s.matchRetention(rules, time.Now())
+}
+
+// ApplyRetention archives the sessions matched by the stored rules and
+// records the run. Without enabled rules nothing runs and nothing is written.
+func (s *SessionService) ApplyRetention() (models.RetentionRun, error) {
+	run := models.RetentionRun{Time: time.Now()}
+
+	data, err := s.retention.Read()
+	if err != nil {
+		return run, err
+	}
+	if !slices.ContainsFunc(data.Rules, func(rule models.RetentionRule) bool { return rule.Enabled }) {
+		return run, nil
+	}
+
+	matches, err := s.matchRetention(data.Rules, run.Time)
+	if err == nil && len(matches) > 0 {
+		var ids []string
+		for _, match := range matches {
+			ids = append(ids, match.Session.ID)
+		}
+		var changed []string
+		changed, err = s.SetSessionsArchived(ids, true)
+		run.Archived = len(changed)
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	saveErr := s.retention.Update(func(data *models.RetentionData) error {
+		data.LastRun = &run
+		return nil
+	})
+	if err == nil {
+		err = saveErr
+	}
+	return run, err
+}
+
+// StartRetentionJob applies the retention rules now and then every interval
+// until ctx is done
+func (s *SessionService) StartRetentionJob(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run, err := s.ApplyRetention()
+			if err != nil {
+				s.logger.Printf("retention: %v", err)
+			} else if run.Archived > 0 {
+				s.logger.Printf("retention: archived %d sessions", run.Archived)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// retentionRule is a rule with its pattern compiled
+type retentionRule struct {
+	models.RetentionRule
+	pattern *regexp.Regexp
+}
+
+// compileRule validates a rule. A rule needs at least one condition so that
+// it cannot archive every session.
+func compileRule(rule models.RetentionRule) (retentionRule, error) {
+	compiled := retentionRule{RetentionRule: rule}
+	if rule.OlderThanDays < 0 || rule.FewerThanPrompts < 0 {
+		return compiled, fmt.Errorf("rule %q: negative values: %w", rule.Name, ErrInvalid)
+	}
+	if rule.OlderThanDays == 0 && rule.FewerThanPrompts == 0 && rule.PromptPattern == "" {
+		return compiled, fmt.Errorf("rule %q has no conditions: %w", rule.Name, ErrInvalid)
+	}
+	if rule.PromptPattern != "" {
+		pattern, err := regexp.Compile(rule.PromptPattern)
+		if err != nil {
+			return compiled, fmt.Errorf("rule %q: %v: %w", rule.Name, err, ErrInvalid)
+		}
+		compiled.pattern = pattern
+	}
+	return compiled, nil
+}
+
+// matchRetention returns the visible sessions matched by any enabled rule,
+// each with the first rule matching it
+func (s *SessionService) matchRetention(rules []models.RetentionRule, now time.Time) ([]models.RetentionMatch, error) {
+	var compiled []retentionRule
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+
+	matches := []models.RetentionMatch{}
+	if len(compiled) == 0 {
+		return matches, nil
+	}
+
+	projects, err := s.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects {
+		for _, info := range project.Sessions {
+			for _, rule := range compiled {
+				if s.ruleMatches(rule, info, now) {
+					matches = append(matches, models.RetentionMatch{Rule: rule.Name, Session: info})
+					break
+				}
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+func (s *SessionService) ruleMatches(rule retentionRule, info models.SessionInfo, now time.Time) bool {
+	if rule.OlderThanDays > 0 {
+		last := info.EndTime
+		if last.IsZero() {
+			last = info.StartTime
+		}
+		if last.After(now.AddDate(0, 0, -rule.OlderThanDays)) {
+			return false
+		}
+	}
+	if rule.FewerThanPrompts > 0 && info.UserMessageCount >= rule.FewerThanPrompts {
+		return false
+	}
+
+	if rule.pattern == nil {
+		return true
+	}
+	session, err := s.parseSessionHeaders(info.EncodedPath, info.ID, nil)
+	if err != nil {
+		return false
+	}
+	// A session without prompts has nothing for the pattern to match
+	prompts := 0
+	for _, msg := range session.Messages {
+		if !msg.Kind.IsHuman() {
+			continue
+		}
+		if !rule.pattern.MatchString(strings.TrimSpace(PromptText(msg))) {
+			return false
+		}
+		prompts++
+	}
+	return prompts > 0
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestPreviewRetentionPattern(t *testing.T) {
+	prompt := func(uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	answer := `{"type":"assistant","uuid":"a1","timestamp":"2026-01-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}`
+
+	s := newTestService(t, map[string]string{
+		"-tmp-app/clear":   jsonl(prompt("p1", "/clear"), answer),
+		"-tmp-app/mixed":   jsonl(prompt("p1", "/clear"), prompt("p2", "Fix the bug"), answer),
+		"-tmp-app/nothing": jsonl(`{"type":"user","uuid":"m1","isMeta":true,"timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":"Caveat"}}`, answer),
+	})
+
+	matches, err := s.PreviewRetention([]models.RetentionRule{{Name: "clears", Enabled: true, PromptPattern: "^/clear$"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, match := range matches {
+		ids = append(ids, match.Session.ID)
+	}
+	if !slices.Equal(ids, []string{"clear"}) {
+		t.Errorf("matched %v, want only the session whose prompts all match", ids)
+	}
+}
+
+func TestApplyRetentionWithoutEnabledRules(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	if _, err := s.ApplyRetention(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.retention.Path()); !os.IsNotExist(err) {
+		t.Errorf("running without rules wrote %s: %v", s.retention.Path(), err)
+	}
+
+	rules := []models.RetentionRule{{Name: "old", Enabled: false, OlderThanDays: 1}}
+	if _, err := s.SaveRetentionRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ApplyRetention(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.GetRetention(); err != nil || data.LastRun != nil {
+		t.Errorf("running only disabled rules recorded %+v, %v", data.LastRun, err)
+	}
+}
@@ -1,120 +1,148 @@
 package services
 
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+	"github.com/yugo-ibuki/claude-code-prompt-share/storage"
 )
 
 type SessionService struct {
-	claudeDir string
+	source    sources.SessionSource
+	claudeDir string // the source's directory when it is local, else empty
+	archive   *storage.Store[ArchiveData]
+	library   *storage.Store[LibraryData]
+	retention *storage.Store[models.RetentionData]
+	trash     *storage.Store[TrashData]
+	summaries summaryCache
+	images    imageCache
+	dataDir   string
+	logger    *log.Logger
+	readOnly  bool
 }
 
-func NewSessionService() *SessionService {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
-	return &SessionService{
-		claudeDir: filepath.Join(homeDir, ".claude"),
+// Option configures a SessionService
+type Option func(*SessionService)
+
+// WithDataDir sets the directory holding the viewer's own state, instead of
+// data/ in the working directory
+func WithDataDir(dir string) Option {
+	return func(s *SessionService) {
+		s.dataDir = dir
 	}
 }
 
-const archiveFile = "data/archived_sessions.json"
-
-// ArchiveData represents the structure of the archive JSON file
-type ArchiveData struct {
-	ArchivedSessions []string `json:"archived_sessions"`
-	ArchivedProjects []string `json:"archived_projects"`
+// WithLogger sets the logger for problems the service works around, instead
+// of the standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(s *SessionService) {
+		s.logger = logger
+	}
 }
 
-// ToggleArchiveSession toggles the archive status of a session
-func (s *SessionService) ToggleArchiveSession(sessionID string) (bool, error) {
-	// Create data directory if not exists
-	if err := os.MkdirAll("data", 0755); err != nil {
-		return false, fmt.Errorf("failed to create data dir: %w", err)
+// WithReadOnly stops the service from modifying anything under ~/.claude
+func WithReadOnly(readOnly bool) Option {
+	return func(s *SessionService) {
+		s.readOnly = readOnly
 	}
+}
 
-	// Load existing
-	archivedSessions, archivedProjects, err := s.loadArchivedData()
-	if err != nil && !os.IsNotExist(err) {
-		return false, err
+// NewSessionService returns a service reading sessions from source. Deleting
+// sessions and saving user commands need a source on the local disk.
+func NewSessionService(source sources.SessionSource, opts ...Option) *SessionService {
+	s := &SessionService{
+		source:  source,
+		dataDir: defaultDataDir,
+		logger:  log.Default(),
 	}
-	if archivedSessions == nil {
-		archivedSessions = make(map[string]bool)
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	// Toggle
-	isArchived := false
-	if archivedSessions[sessionID] {
-		delete(archivedSessions, sessionID)
-		isArchived = false
-	} else {
-		archivedSessions[sessionID] = true
-		isArchived = true
+	storeOpts := []storage.Option{storage.WithLogger(s.logger)}
+	s.archive = storage.New[ArchiveData](filepath.Join(s.dataDir, "archived_sessions.json"), 1, storeOpts...)
+	s.library = storage.New[LibraryData](filepath.Join(s.dataDir, "prompt_library.json"), 1, storeOpts...)
+	s.retention = storage.New[models.RetentionData](filepath.Join(s.dataDir, "retention_rules.json"), 1, storeOpts...)
+	s.trash = storage.New[TrashData](filepath.Join(s.dataDir, "trash.json"), 1, storeOpts...)
+	if local, ok := source.(sources.Local); ok {
+		s.claudeDir = local.Root()
 	}
+	return s
+}
 
-	// Save
-	if err := s.saveArchivedData(archivedSessions, archivedProjects); err != nil {
-		return false, err
-	}
+// defaultDataDir holds the viewer's own state, relative to the working
+// directory
+const defaultDataDir = "data"
+
+var (
+	// ErrNotFound is returned when a requested session item does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write would overwrite existing data.
+	ErrConflict = errors.New("already exists")
+	// ErrInvalid is returned when user input fails validation.
+	ErrInvalid = errors.New("invalid input")
+	// ErrReadOnly is returned for changes to Claude Code's files in read-only mode.
+	ErrReadOnly = errors.New("read-only mode")
+)
 
-	return isArchived, nil
+// ReadOnly reports whether the service is in read-only mode, either set or
+// because its source is not a local directory
+func (s *SessionService) ReadOnly() bool {
+	return s.readOnly || s.claudeDir == ""
 }
 
-// ToggleArchiveProject toggles the archive status of a project
-func (s *SessionService) ToggleArchiveProject(encodedPath string) (bool, error) {
-	// Create data directory if not exists
-	if err := os.MkdirAll("data", 0755); err != nil {
-		return false, fmt.Errorf("failed to create data dir: %w", err)
-	}
+// ArchiveData represents the structure of the archive JSON file
+type ArchiveData struct {
+	ArchivedSessions []string                            `json:"archived_sessions"`
+	ArchivedProjects []string                            `json:"archived_projects"`
+	Sessions         map[string]models.SessionAnnotation `json:"sessions,omitempty"`
+}
 
-	// Load existing
-	archivedSessions, archivedProjects, err := s.loadArchivedData()
-	if err != nil && !os.IsNotExist(err) {
-		return false, err
-	}
-	if archivedProjects == nil {
-		archivedProjects = make(map[string]bool)
-	}
+// ToggleArchiveSession toggles the archive status of a session
+func (s *SessionService) ToggleArchiveSession(sessionID string) (bool, error) {
+	var isArchived bool
+	err := s.archive.Update(func(data *ArchiveData) error {
+		data.ArchivedSessions, isArchived = toggle(data.ArchivedSessions, sessionID)
+		return nil
+	})
+	return isArchived, err
+}
 
-	// Toggle
-	isArchived := false
-	if archivedProjects[encodedPath] {
-		delete(archivedProjects, encodedPath)
-		isArchived = false
-	} else {
-		archivedProjects[encodedPath] = true
-		isArchived = true
-	}
+// ToggleArchiveProject toggles the archive status of a project
+func (s *SessionService) ToggleArchiveProject(encodedPath string) (bool, error) {
+	var isArchived bool
+	err := s.archive.Update(func(data *ArchiveData) error {
+		data.ArchivedProjects, isArchived = toggle(data.ArchivedProjects, encodedPath)
+		return nil
+	})
+	return isArchived, err
+}
 
-	// Save
-	if err := s.saveArchivedData(archivedSessions, archivedProjects); err != nil {
-		return false, err
+// toggle removes id from list if present and adds it otherwise, reporting
+// whether it is now in the list
+func toggle(list []string, id string) ([]string, bool) {
+	if i := slices.Index(list, id); i >= 0 {
+		return slices.Delete(list, i, i+1), false
 	}
-
-	return isArchived, nil
+	return append(list, id), true
 }
 
 func (s *SessionService) loadArchivedData() (map[string]bool, map[string]bool, error) {
-	file, err := os.ReadFile(archiveFile)
+	data, err := s.archive.Read()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var data ArchiveData
-	if err := json.Unmarshal(file, &data); err != nil {
-		return nil, nil, err
-	}
-
 	sessions := make(map[string]bool)
 	for _, id := range data.ArchivedSessions {
 		sessions[id] = true
@@ -128,34 +156,16 @@ func (s *SessionService) loadArchivedData() (map[string]bool, map[string]bool, e
 	return sessions, projects, nil
 }
 
-func (s *SessionService) saveArchivedData(sessions map[string]bool, projects map[string]bool) error {
-	var sessionList []string
-	for id := range sessions {
-		sessionList = append(sessionList, id)
-	}
-
-	var projectList []string
-	for id := range projects {
-		projectList = append(projectList, id)
-	}
-
-	data := ArchiveData{
-		ArchivedSessions: sessionList,
-		ArchivedProjects: projectList,
-	}
-	bytes, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(archiveFile, bytes, 0644)
-}
-
 // GetAllProjects returns all Claude Code projects
 func (s *SessionService) GetAllProjects() ([]models.Project, error) {
-	projectsDir := filepath.Join(s.claudeDir, "projects")
+	return s.walkSessions(nil)
+}
 
-	entries, err := os.ReadDir(projectsDir)
+// walkSessions returns the visible projects like GetAllProjects, calling visit
+// with every session as it is parsed, so that callers summing up sessions do
+// not parse them a second time. The project passed to visit has no sessions.
+func (s *SessionService) walkSessions(visit func(project models.Project, info models.SessionInfo, session models.Session)) ([]models.Project, error) {
+	encodedPaths, err := s.source.Projects()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read projects directory: %w", err)
 	}
@@ -167,72 +177,85 @@ func (s *SessionService) GetAllProjects() ([]models.Project, error) {
 	}
 
 	var projects []models.Project
-	for _, entry := range entries {
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
-		}
-
-		encodedPath := entry.Name()
-
+	for _, encodedPath := range encodedPaths {
 		// Skip archived projects
 		if archivedProjects[encodedPath] {
 			continue
 		}
 
-		decodedPath := s.decodeProjectPath(encodedPath)
+		project := models.Project{
+			EncodedPath: encodedPath,
+			DecodedPath: s.decodeProjectPath(encodedPath),
+		}
 
-		sessions, err := s.getProjectSessions(encodedPath)
+		var visitSession func(models.SessionInfo, models.Session)
+		if visit != nil {
+			visitSession = func(info models.SessionInfo, session models.Session) {
+				visit(project, info, session)
+			}
+		}
+		sessions, err := s.getProjectSessions(encodedPath, visitSession)
 		if err != nil {
+			s.logger.Printf("sessions: skipping project %s: %v", encodedPath, err)
 			continue
 		}
 
-		projects = append(projects, models.Project{
-			EncodedPath: encodedPath,
-			DecodedPath: decodedPath,
-			Sessions:    sessions,
-		})
+		project.Sessions = sessions
+		projects = append(projects, project)
 	}
 
 	return projects, nil
 }
 
 // GetSessionsByProject returns all sessions for a specific project
-func (s *SessionService) getProjectSessions(encodedPath string) ([]models.SessionInfo, error) {
-	projectDir := filepath.Join(s.claudeDir, "projects", encodedPath)
+func (s *SessionService) getProjectSessions(encodedPath string, visit func(models.SessionInfo, models.Session)) ([]models.SessionInfo, error) {
+	// Load archived list
+	archivedSessions, _, _ := s.loadArchivedData() // Ignore error
 
-	entries, err := os.ReadDir(projectDir)
+	// Skip archived sessions
+	return s.scanProjectSessions(encodedPath, func(sessionID string) bool {
+		return !archivedSessions[sessionID]
+	}, visit)
+}
+
+// scanProjectSessions returns the sessions of a project whose ID passes keep,
+// newest first. When visit is set, it is called with every session kept, in
+// file order, as it is parsed.
+func (s *SessionService) scanProjectSessions(encodedPath string, keep func(sessionID string) bool, visit func(models.SessionInfo, models.Session)) ([]models.SessionInfo, error) {
+	sessionIDs, err := s.source.Sessions(encodedPath)
 	if err != nil {
 		return nil, err
 	}
 
-	// Load archived list
-	archivedSessions, _, _ := s.loadArchivedData() // Ignore error
-	if archivedSessions == nil {
-		archivedSessions = make(map[string]bool)
-	}
+	annotations := s.loadSessionAnnotations()
+	summaries := s.summaryIndex(encodedPath)
 
 	var sessions []models.SessionInfo
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+	for _, sessionID := range sessionIDs {
+		// Skip agent sessions
+		if strings.HasPrefix(sessionID, "agent-") {
 			continue
 		}
 
-		// Skip agent sessions
-		if strings.HasPrefix(entry.Name(), "agent-") {
+		if !keep(sessionID) {
 			continue
 		}
 
-		sessionID := strings.TrimSuffix(entry.Name(), ".jsonl")
-		
-		// Skip archived sessions
-		if archivedSessions[sessionID] {
+		session, err := s.parseSessionHeaders(encodedPath, sessionID, summaries)
+		if err != nil {
+			s.logger.Printf("sessions: skipping %s/%s: %v", encodedPath, sessionID, err)
 			continue
 		}
+		sessionInfo := newSessionInfo(encodedPath, sessionID, session)
 
-		sessionInfo, err := s.getSessionInfo(encodedPath, sessionID)
-		if err != nil {
+		// Files holding only summary records are not sessions
+		if sessionInfo.MessageCount == 0 {
 			continue
 		}
+		applyAnnotation(&sessionInfo, annotations[sessionID])
+		if visit != nil {
+			visit(sessionInfo, session)
+		}
 
 		sessions = append(sessions, sessionInfo)
 	}
@@ -246,37 +269,35 @@ func (s *SessionService) getProjectSessions(encodedPath string) ([]models.Sessio
 }
 
 // GetSessionInfo returns basic information about a session
-func (s *SessionService) getSessionInfo(encodedPath, sessionID string) (models.SessionInfo, error) {
-	session, err := s.GetSession(encodedPath, sessionID)
+func (s *SessionService) getSessionInfo(encodedPath, sessionID string, summaries map[string]string) (models.SessionInfo, error) {
+	session, err := s.parseSessionHeaders(encodedPath, sessionID, summaries)
 	if err != nil {
 		return models.SessionInfo{}, err
 	}
+	return newSessionInfo(encodedPath, sessionID, session), nil
+}
 
+// newSessionInfo sums up a parsed session
+func newSessionInfo(encodedPath, sessionID string, session models.Session) models.SessionInfo {
 	firstMessage := ""
-	if len(session.Messages) > 0 {
-		firstMessage = session.Messages[0].Content
-		if len(firstMessage) > 100 {
-			firstMessage = firstMessage[:100] + "..."
-		}
-	}
-
 	userCount := 0
 	assistantCount := 0
 	for _, msg := range session.Messages {
-		// Skip empty messages (tool use/results)
-		if strings.TrimSpace(msg.Content) == "" {
-			continue
-		}
-
-		if msg.Role == "user" {
+		// Only count what people typed, not tool results or injected content
+		if msg.Kind.IsHuman() {
+			if userCount == 0 {
+				firstMessage = Truncate(PromptText(msg), 100)
+			}
 			userCount++
-		} else if msg.Role == "assistant" {
+		} else if msg.Role == "assistant" && strings.TrimSpace(msg.Content) != "" {
+			// Skip assistant messages that only carry tool calls
 			assistantCount++
 		}
 	}
 
 	return models.SessionInfo{
 		ID:                    sessionID,
+		EncodedPath:           encodedPath,
 		ProjectPath:           session.ProjectPath,
 		ProjectName:           session.ProjectName,
 		StartTime:             session.StartTime,
@@ -285,53 +306,115 @@ func (s *SessionService) getSessionInfo(encodedPath, sessionID string) (models.S
 		UserMessageCount:      userCount,
 		AssistantMessageCount: assistantCount,
 		FirstMessage:          firstMessage,
-	}, nil
+		Title:                 session.Summary,
+		GitBranch:             session.GitBranch,
+		GitBranches:           session.Metadata.GitBranches,
+		SkippedLines:          session.Diagnostics.SkippedLines,
+	}
 }
 
 // GetSession returns a complete session with all messages
 func (s *SessionService) GetSession(encodedPath, sessionID string) (models.Session, error) {
-	sessionFile := filepath.Join(s.claudeDir, "projects", encodedPath, sessionID+".jsonl")
+	return s.parseSession(encodedPath, sessionID, s.summaryIndex(encodedPath))
+}
+
+// parseSession reads a session file. Summaries maps leaf uuids to summary
+// records of the project; when nil, summaries are not attached.
+func (s *SessionService) parseSession(encodedPath, sessionID string, summaries map[string]string) (models.Session, error) {
+	session, _, err := s.readSession(encodedPath, sessionID, summaries, parseFull)
+	return session, err
+}
+
+// parseSessionHeaders is parseSession without images, file diffs and todo
+// lists, for going through many sessions
+func (s *SessionService) parseSessionHeaders(encodedPath, sessionID string, summaries map[string]string) (models.Session, error) {
+	session, _, err := s.readSession(encodedPath, sessionID, summaries, parseHeaders)
+	return session, err
+}
 
-	file, err := os.Open(sessionFile)
+// readSession is parseSession that also returns the files the session edited.
+// Lines that cannot be parsed are skipped and, with line and content block
+// types the parser does not know, listed in the session's Diagnostics; only
+// failing to read the file is an error.
+func (s *SessionService) readSession(encodedPath, sessionID string, summaries map[string]string, mode parseMode) (models.Session, []*fileChange, error) {
+	file, err := s.source.Open(encodedPath, sessionID)
 	if err != nil {
-		return models.Session{}, fmt.Errorf("failed to open session file: %w", err)
+		return models.Session{}, nil, fmt.Errorf("failed to open session file: %w", err)
 	}
 	defer file.Close()
 
 	var messages []models.ConversationMessage
 	var startTime, endTime time.Time
+	diagnostics := newDiagnosticsCollector()
+
+	// parentOf records the parent of every line carrying a uuid, including
+	// attachments and system lines that are not kept as messages, so that
+	// message parents can be resolved through them.
+	parentOf := make(map[string]string)
+	kept := make(map[string]bool)
+	var uuids []string
+	origins := make(map[string]editOrigin)
+	metadata := newMetadataCollector()
+	var todos todoTracker
+
+	err = forEachLine(file, func(number int, line []byte) error {
+		jsonlMsg, err := decodeLine(line, mode)
+		if err != nil {
+			diagnostics.lineError(number, err)
+			return nil
+		}
+		diagnostics.add(number, jsonlMsg)
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for large messages
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		var jsonlMsg models.JSONLMessage
-		if err := json.Unmarshal(scanner.Bytes(), &jsonlMsg); err != nil {
-			continue
+		if jsonlMsg.UUID != "" {
+			uuids = append(uuids, jsonlMsg.UUID)
+			if jsonlMsg.ParentUUID != nil {
+				parentOf[jsonlMsg.UUID] = *jsonlMsg.ParentUUID
+			}
 		}
 
+		metadata.add(jsonlMsg)
+
 		// Skip non-message types
 		if jsonlMsg.Type != "user" && jsonlMsg.Type != "assistant" {
-			continue
+			return nil
 		}
 
 		if jsonlMsg.Message == nil {
-			continue
+			return nil
 		}
 
 		content := s.extractContent(jsonlMsg.Message.Content)
+		toolCalls, toolResults := extractToolBlocks(jsonlMsg.Message.Content)
 
 		msg := models.ConversationMessage{
-			UUID:      jsonlMsg.UUID,
-			Role:      jsonlMsg.Message.Role,
-			Content:   content,
-			Timestamp: jsonlMsg.Timestamp,
-			IsAgent:   false,
+			UUID:        jsonlMsg.UUID,
+			Role:        jsonlMsg.Message.Role,
+			Content:     content,
+			Timestamp:   jsonlMsg.Timestamp,
+			Model:       jsonlMsg.Message.Model,
+			IsAgent:     false,
+			ToolCalls:   toolCalls,
+			ToolResults: toolResults,
+		}
+		msg.Kind = ClassifyMessage(jsonlMsg, content, toolResults)
+		if jsonlMsg.ParentUUID != nil {
+			msg.ParentUUID = *jsonlMsg.ParentUUID
+		}
+
+		if mode == parseFull {
+			s.attachImages(&msg, jsonlMsg.Message.Content)
+			todos.add(jsonlMsg, msg)
+			if len(toolResults) == 1 {
+				if origin, ok := parseEditOrigin(jsonlMsg.ToolUseResult); ok {
+					origins[toolResults[0].ToolUseID] = origin
+				}
+			}
 		}
 
 		messages = append(messages, msg)
+		if msg.UUID != "" {
+			kept[msg.UUID] = true
+		}
 
 		// Track start and end times
 		if startTime.IsZero() || jsonlMsg.Timestamp.Before(startTime) {
@@ -340,26 +423,81 @@ func (s *SessionService) GetSession(encodedPath, sessionID string) (models.Sessi
 		if jsonlMsg.Timestamp.After(endTime) {
 			endTime = jsonlMsg.Timestamp
 		}
+		return nil
+	})
+	if err != nil {
+		return models.Session{}, nil, fmt.Errorf("error reading session file: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return models.Session{}, fmt.Errorf("error reading session file: %w", err)
+	for i := range messages {
+		messages[i].ParentUUID = resolveParent(messages[i].ParentUUID, parentOf, kept)
 	}
 
 	decodedPath := s.decodeProjectPath(encodedPath)
 	projectName := filepath.Base(decodedPath)
 
-	return models.Session{
+	session := models.Session{
 		ID:          sessionID,
 		ProjectPath: decodedPath,
 		ProjectName: projectName,
 		Messages:    messages,
 		StartTime:   startTime,
 		EndTime:     endTime,
-	}, nil
+		GitBranch:   metadata.branch,
+		Metadata:    metadata.result(startTime, endTime),
+		Todos:       todos.snapshots,
+		Diagnostics: diagnostics.diagnostics,
+	}
+	attachSummaries(&session, uuids, summaries, parentOf, kept)
+
+	s.linkCommands(session.Messages, projectRoot(session))
+
+	var changes []*fileChange
+	if mode == parseFull {
+		changes = attachDiffs(session.Messages, origins, projectRoot(session))
+	}
+
+	return session, changes, nil
+}
+
+// projectWorkDir returns the directory the latest session of a project ran
+// in, or the decoded path when the project has no session recording one
+func (s *SessionService) projectWorkDir(encodedPath string) string {
+	infos, err := s.scanProjectSessions(encodedPath, func(string) bool { return true }, nil)
+	if err == nil && len(infos) > 0 {
+		if session, err := s.parseSessionHeaders(encodedPath, infos[0].ID, nil); err == nil {
+			return projectRoot(session)
+		}
+	}
+	return s.decodeProjectPath(encodedPath)
+}
+
+// projectRoot returns the directory a session ran in. Encoded paths turn both
+// "/" and "-" into "-", so the decoded path is only a guess for sessions that
+// did not record their working directory.
+func projectRoot(session models.Session) string {
+	if cwds := session.Metadata.Cwds; len(cwds) > 0 {
+		return cwds[0]
+	}
+	return session.ProjectPath
+}
+
+// resolveParent walks up the parent chain until it reaches a uuid that was
+// kept as a conversation message.
+func resolveParent(parent string, parentOf map[string]string, kept map[string]bool) string {
+	seen := make(map[string]bool)
+	for parent != "" && !kept[parent] {
+		if seen[parent] {
+			return ""
+		}
+		seen[parent] = true
+		parent = parentOf[parent]
+	}
+	return parent
 }
 
-// SearchSessions searches for sessions containing the query in messages
+// SearchSessions searches for sessions containing the query in their title,
+// tags or messages. Words starting with "#" only match sessions with that tag.
 func (s *SessionService) SearchSessions(query string) ([]models.SessionInfo, error) {
 	projects, err := s.GetAllProjects()
 	if err != nil {
@@ -367,21 +505,13 @@ func (s *SessionService) SearchSessions(query string) ([]models.SessionInfo, err
 	}
 
 	var results []models.SessionInfo
-	query = strings.ToLower(query)
+	text, tags := splitTagQuery(query)
+	text = strings.ToLower(text)
 
 	for _, project := range projects {
 		for _, sessionInfo := range project.Sessions {
-			session, err := s.GetSession(project.EncodedPath, sessionInfo.ID)
-			if err != nil {
-				continue
-			}
-
-			// Search in messages
-			for _, msg := range session.Messages {
-				if strings.Contains(strings.ToLower(msg.Content), query) {
-					results = append(results, sessionInfo)
-					break
-				}
+			if s.matchesQuery(sessionInfo, text, tags) {
+				results = append(results, sessionInfo)
 			}
 		}
 	}
@@ -389,8 +519,72 @@ func (s *SessionService) SearchSessions(query string) ([]models.SessionInfo, err
 	return results, nil
 }
 
+// matchesQuery reports whether a session has all tags and contains text, in
+// lowercase, in its title or any message
+func (s *SessionService) matchesQuery(sessionInfo models.SessionInfo, text string, tags []string) bool {
+	if !hasAllTags(sessionInfo.Tags, tags) {
+		return false
+	}
+	if text == "" || strings.Contains(strings.ToLower(sessionInfo.Title), text) {
+		return true
+	}
+
+	session, err := s.parseSessionHeaders(sessionInfo.EncodedPath, sessionInfo.ID, nil)
+	if err != nil {
+		return false
+	}
+
+	// Search in messages
+	for _, msg := range session.Messages {
+		if strings.Contains(strings.ToLower(msg.Content), text) {
+			return true
+		}
+	}
+	return false
+}
+
 // extractContent extracts text content from various message content formats
 func (s *SessionService) extractContent(content interface{}) string {
+	return extractText(content)
+}
+
+// extractToolBlocks extracts tool_use and tool_result blocks from message content
+func extractToolBlocks(content interface{}) ([]models.ToolCall, []models.ToolResult) {
+	items, ok := content.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var calls []models.ToolCall
+	var results []models.ToolResult
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch itemMap["type"] {
+		case "tool_use":
+			call := models.ToolCall{}
+			call.ID, _ = itemMap["id"].(string)
+			call.Name, _ = itemMap["name"].(string)
+			call.Input, _ = itemMap["input"].(map[string]interface{})
+			calls = append(calls, call)
+		case "tool_result":
+			result := models.ToolResult{}
+			result.ToolUseID, _ = itemMap["tool_use_id"].(string)
+			result.IsError, _ = itemMap["is_error"].(bool)
+			if itemMap["content"] != nil {
+				result.Content = extractText(itemMap["content"])
+			}
+			results = append(results, result)
+		}
+	}
+	return calls, results
+}
+
+// extractText extracts text from a string or a list of text blocks
+func extractText(content interface{}) string {
 	switch v := content.(type) {
 	case string:
 		return v
@@ -424,7 +618,7 @@ func (s *SessionService) decodeProjectPath(encoded string) string {
 
 // GetProjectSessionsInfo returns session information for a specific project
 func (s *SessionService) GetProjectSessionsInfo(encodedPath string) ([]models.SessionInfo, error) {
-	return s.getProjectSessions(encodedPath)
+	return s.getProjectSessions(encodedPath, nil)
 }
 
 // walkDir walks through directory and returns file entries
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+)
+
+// newTestService returns a service reading the given session files, named
+// by project and session ID such as "-tmp-app/s1", from memory
+func newTestService(t *testing.T, files map[string]string) *SessionService {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys["projects/"+name+".jsonl"] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return NewSessionService(sources.NewFS(fsys), WithDataDir(t.TempDir()))
+}
+
+// jsonl joins lines into the content of a session file
+func jsonl(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// forkedSession is a session whose tool calls fork the parentUuid chain and
+// whose last answer hangs off a line that is not a message
+var forkedSession = jsonl(
+	`{"type":"user","uuid":"p1","parentUuid":null,"timestamp":"2026-01-01T10:00:00Z","cwd":"/tmp/app","message":{"role":"user","content":"Fix the bug"}}`,
+	`{"type":"assistant","uuid":"a1","parentUuid":"p1","timestamp":"2026-01-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"text","text":"Looking"},{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/tmp/app/a.go"}},{"type":"tool_use","id":"t2","name":"Read","input":{"file_path":"/tmp/app/b.go"}}]}}`,
+	`{"type":"user","uuid":"r1","parentUuid":"a1","timestamp":"2026-01-01T10:00:02Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package a"}]}}`,
+	`{"type":"user","uuid":"r2","parentUuid":"a1","timestamp":"2026-01-01T10:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"package b"}]}}`,
+	`{"type":"system","uuid":"x1","parentUuid":"r2","timestamp":"2026-01-01T10:00:04Z"}`,
+	`{"type":"assistant","uuid":"a2","parentUuid":"x1","timestamp":"2026-01-01T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}`,
+	`{"type":"user","uuid":"p2","parentUuid":"a2","timestamp":"2026-01-01T10:01:00Z","message":{"role":"user","content":"Thanks"}}`,
+	`{"type":"assistant","uuid":"a3","parentUuid":"p2","timestamp":"2026-01-01T10:01:01Z","message":{"role":"assistant","content":[{"type":"text","text":"You're welcome"}]}}`,
+	`not json`,
+)
+
+func TestGetSessionResolvesParentsThroughSkippedLines(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	session, err := s.GetSession("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"p1": "",
+		"a1": "p1",
+		"r1": "a1",
+		"r2": "a1",
+		"a2": "r2", // through the system line x1
+		"p2": "a2",
+		"a3": "p2",
+	}
+	if len(session.Messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(session.Messages), len(want))
+	}
+	for _, msg := range session.Messages {
+		if parent, ok := want[msg.UUID]; !ok || msg.ParentUUID != parent {
+			t.Errorf("parent of %s = %q, want %q", msg.UUID, msg.ParentUUID, parent)
+		}
+	}
+
+	if session.Diagnostics.SkippedLines != 1 {
+		t.Errorf("skipped lines = %d, want 1", session.Diagnostics.SkippedLines)
+	}
+	if root := projectRoot(session); root != "/tmp/app" {
+		t.Errorf("project root = %q, want the recorded cwd /tmp/app", root)
+	}
+}
+
+func TestGetSessionNotFound(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	for _, id := range []string{"missing", "../s1"} {
+		if _, err := s.GetSession("-tmp-app", id); err == nil {
+			t.Errorf("GetSession(%q) succeeded", id)
+		}
+	}
+}
+
+func TestSourceOutsideLocalDiskIsReadOnly(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	if !s.ReadOnly() {
+		t.Error("ReadOnly() = false for a source that is not a local directory")
+	}
+	if _, err := s.TrashSession("-tmp-app", "s1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("TrashSession() error = %v, want ErrReadOnly", err)
+	}
+}
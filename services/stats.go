@@ -0,0 +1,149 @@
+package services
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// topN is how many models and tools the stats keep
+const topN = 15
+
+// GetStats computes activity statistics over the visible projects. When since
+// is set, only sessions active after it and prompts sent after it count.
+func (s *SessionService) GetStats(since time.Time) (models.Stats, error) {
+	stats := models.Stats{
+		ByProject:     []models.ProjectStats{},
+		PromptsPerDay: []models.DayCount{},
+	}
+	if !since.IsZero() {
+		stats.Since = &since
+	}
+
+	perDay := make(map[string]int)
+	modelCounts := make(map[string]int)
+	toolCounts := make(map[string]int)
+	byProject := make(map[string]*models.ProjectStats)
+	var totalDuration time.Duration
+
+	projects, err := s.walkSessions(func(project models.Project, info models.SessionInfo, session models.Session) {
+		if !since.IsZero() && info.EndTime.Before(since) {
+			return
+		}
+
+		ps, ok := byProject[project.EncodedPath]
+		if !ok {
+			ps = &models.ProjectStats{
+				EncodedPath: project.EncodedPath,
+				Name:        filepath.Base(project.DecodedPath),
+			}
+			byProject[project.EncodedPath] = ps
+		}
+
+		ps.Sessions++
+		totalDuration += session.EndTime.Sub(session.StartTime)
+		if session.EndTime.After(ps.LastActivity) {
+			ps.LastActivity = session.EndTime
+		}
+
+		for _, msg := range session.Messages {
+			if !since.IsZero() && msg.Timestamp.Before(since) {
+				continue
+			}
+			if msg.Kind.IsHuman() {
+				ps.Prompts++
+				local := msg.Timestamp.Local()
+				perDay[local.Format("2006-01-02")]++
+				stats.Heatmap[local.Weekday()][local.Hour()]++
+			}
+			if msg.Role == "assistant" && msg.Model != "" && msg.Model != syntheticModel {
+				modelCounts[msg.Model]++
+			}
+			for _, call := range msg.ToolCalls {
+				toolCounts[call.Name]++
+			}
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for _, project := range projects {
+		ps, ok := byProject[project.EncodedPath]
+		if !ok {
+			continue
+		}
+		stats.Projects++
+		stats.Sessions += ps.Sessions
+		stats.Prompts += ps.Prompts
+		stats.ByProject = append(stats.ByProject, *ps)
+	}
+
+	if stats.Sessions > 0 {
+		stats.AvgSessionMinutes = totalDuration.Minutes() / float64(stats.Sessions)
+		stats.AvgPromptsPerSession = float64(stats.Prompts) / float64(stats.Sessions)
+	}
+
+	// Most active projects first
+	sort.Slice(stats.ByProject, func(i, j int) bool {
+		if stats.ByProject[i].Prompts != stats.ByProject[j].Prompts {
+			return stats.ByProject[i].Prompts > stats.ByProject[j].Prompts
+		}
+		return stats.ByProject[i].Name < stats.ByProject[j].Name
+	})
+
+	stats.PromptsPerDay = fillDays(perDay, since)
+	stats.Models = topCounts(modelCounts, topN)
+	stats.Tools = topCounts(toolCounts, topN)
+
+	return stats, nil
+}
+
+// fillDays returns the counts of every day from since, or the first day with
+// a count, until today, including days without any
+func fillDays(perDay map[string]int, since time.Time) []models.DayCount {
+	days := []models.DayCount{}
+
+	first := since
+	if first.IsZero() {
+		for date := range perDay {
+			day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+			if err == nil && (first.IsZero() || day.Before(first)) {
+				first = day
+			}
+		}
+	}
+	if first.IsZero() {
+		return days
+	}
+
+	first = first.Local()
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.Local)
+	for now := time.Now(); !day.After(now); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		days = append(days, models.DayCount{Date: date, Count: perDay[date]})
+	}
+	return days
+}
+
+// topCounts returns the n largest counts, largest first
+func topCounts(counts map[string]int, n int) []models.NameCount {
+	result := []models.NameCount{}
+	for name, count := range counts {
+		result = append(result, models.NameCount{Name: name, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
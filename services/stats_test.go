@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetStats(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": forkedSession,
+		"-tmp-app/s2": forkedSession,
+		"-tmp-web/s3": forkedSession,
+	})
+
+	tests := []struct {
+		name                        string
+		since                       time.Time
+		projects, sessions, prompts int
+	}{
+		{"all time", time.Time{}, 2, 3, 6},
+		{"during the sessions", time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC), 2, 3, 3},
+		{"after the sessions", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := s.GetStats(tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Projects != tt.projects || stats.Sessions != tt.sessions || stats.Prompts != tt.prompts {
+				t.Errorf("got %d projects, %d sessions, %d prompts; want %d, %d, %d",
+					stats.Projects, stats.Sessions, stats.Prompts, tt.projects, tt.sessions, tt.prompts)
+			}
+			if tt.sessions == 0 {
+				return
+			}
+			if first := stats.ByProject[0]; first.Name != "app" || first.Sessions != 2 {
+				t.Errorf("most active project = %+v, want app with 2 sessions", first)
+			}
+		})
+	}
+
+	stats, err := s.GetStats(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Tools) != 1 || stats.Tools[0].Name != "Read" || stats.Tools[0].Count != 6 {
+		t.Errorf("tools = %+v, want Read called 6 times", stats.Tools)
+	}
+}
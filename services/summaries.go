@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// summaryCache keeps the summary records of every session file read, until
+// the file changes
+type summaryCache struct {
+	mu       sync.Mutex
+	projects map[string]map[string]summaryFile // by encoded path, then session ID
+}
+
+// summaryFile is the summary records of a session file as it was when read
+type summaryFile struct {
+	modTime   time.Time
+	size      int64
+	summaries map[string]string
+}
+
+// summaryIndex maps leaf uuids to the summary records found in any session
+// file of a project. Claude Code writes the summary of a conversation into
+// the file of the session that resumes it, so a single file is not enough.
+// Only the files that changed since the last call are read again.
+func (s *SessionService) summaryIndex(encodedPath string) map[string]string {
+	index := make(map[string]string)
+
+	sessionIDs, err := s.source.Sessions(encodedPath)
+	if err != nil {
+		return index
+	}
+
+	s.summaries.mu.Lock()
+	cached := s.summaries.projects[encodedPath]
+	s.summaries.mu.Unlock()
+
+	files := make(map[string]summaryFile, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		info, err := s.source.Stat(encodedPath, sessionID)
+		if err != nil {
+			continue
+		}
+
+		file, ok := cached[sessionID]
+		if !ok || !file.modTime.Equal(info.ModTime()) || file.size != info.Size() {
+			summaries, err := s.readSummaries(encodedPath, sessionID)
+			if err != nil {
+				// Use what could be read, and read the file again next time
+				for leafUUID, summary := range summaries {
+					index[leafUUID] = summary
+				}
+				continue
+			}
+			file = summaryFile{modTime: info.ModTime(), size: info.Size(), summaries: summaries}
+		}
+		files[sessionID] = file
+
+		for leafUUID, summary := range file.summaries {
+			index[leafUUID] = summary
+		}
+	}
+
+	s.summaries.mu.Lock()
+	if s.summaries.projects == nil {
+		s.summaries.projects = make(map[string]map[string]summaryFile)
+	}
+	s.summaries.projects[encodedPath] = files
+	s.summaries.mu.Unlock()
+
+	return index
+}
+
+// readSummaries returns the summary records of a session file by leaf uuid
+func (s *SessionService) readSummaries(encodedPath, sessionID string) (map[string]string, error) {
+	file, err := s.source.Open(encodedPath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	summaries := make(map[string]string)
+	err = forEachLine(file, func(_ int, line []byte) error {
+		if !bytes.Contains(line, []byte(`"summary"`)) {
+			return nil
+		}
+
+		var record struct {
+			Type     string `json:"type"`
+			Summary  string `json:"summary"`
+			LeafUUID string `json:"leafUuid"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil
+		}
+		if record.Type == "summary" && record.LeafUUID != "" && record.Summary != "" {
+			summaries[record.LeafUUID] = record.Summary
+		}
+		return nil
+	})
+	return summaries, err
+}
+
+// attachSummaries marks the message each summary's branch ends at and uses
+// the summary of the latest branch as the session summary. Leaves that are
+// not conversation messages resolve to their nearest message ancestor.
+func attachSummaries(session *models.Session, uuids []string, summaries map[string]string, parentOf map[string]string, kept map[string]bool) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	position := make(map[string]int)
+	for i, msg := range session.Messages {
+		position[msg.UUID] = i
+	}
+
+	latest := -1
+	for _, uuid := range uuids {
+		summary, ok := summaries[uuid]
+		if !ok {
+			continue
+		}
+
+		leaf := uuid
+		if !kept[leaf] {
+			leaf = resolveParent(parentOf[leaf], parentOf, kept)
+		}
+		i, ok := position[leaf]
+		if !ok {
+			continue
+		}
+
+		session.Messages[i].Summary = summary
+		session.Summaries = append(session.Summaries, models.SessionSummary{
+			Summary:  summary,
+			LeafUUID: uuid,
+		})
+		if i >= latest {
+			latest = i
+			session.Summary = summary
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// Todo statuses written by TodoWrite, besides "in_progress"
+const (
+	todoPending   = "pending"
+	todoCompleted = "completed"
+)
+
+// todoRemoved is the TodoChange.To of an item dropped from the list
+const todoRemoved = "removed"
+
+// GetSessionTodos returns how the agent's todo list evolved in a session,
+// with how many items were planned and completed
+func (s *SessionService) GetSessionTodos(encodedPath, sessionID string) (models.TodoTimeline, error) {
+	session, err := s.parseSession(encodedPath, sessionID, nil)
+	if err != nil {
+		return models.TodoTimeline{}, err
+	}
+
+	timeline := models.TodoTimeline{Snapshots: session.Todos}
+	if timeline.Snapshots == nil {
+		timeline.Snapshots = []models.TodoSnapshot{}
+	}
+
+	planned := make(map[string]bool)
+	completed := make(map[string]bool)
+	for _, snapshot := range session.Todos {
+		for _, todo := range snapshot.Todos {
+			planned[todo.Content] = true
+			if todo.Status == todoCompleted {
+				completed[todo.Content] = true
+			}
+		}
+	}
+	timeline.Planned, timeline.Completed = len(planned), len(completed)
+
+	return timeline, nil
+}
+
+// todoTracker records the todo list each time the agent changes it
+type todoTracker struct {
+	snapshots []models.TodoSnapshot
+	prompt    string // uuid of the latest prompt
+}
+
+// add records the todo lists a line carries: the input of TodoWrite calls,
+// or else the list Claude Code stores in the line's todos field
+func (t *todoTracker) add(line models.JSONLMessage, msg models.ConversationMessage) {
+	if msg.Kind.IsHuman() {
+		t.prompt = msg.UUID
+	}
+
+	wrote := false
+	for _, call := range msg.ToolCalls {
+		if call.Name != "TodoWrite" {
+			continue
+		}
+		items, _ := call.Input["todos"].([]interface{})
+		t.record(msg.UUID, msg.Timestamp, parseTodos(items))
+		wrote = true
+	}
+
+	// The field is empty on lines written without a todo list, so only a
+	// non-empty one is taken as a change
+	var items []interface{}
+	if !wrote && len(line.Todos) > 0 && json.Unmarshal(line.Todos, &items) == nil && len(items) > 0 {
+		t.record(msg.UUID, msg.Timestamp, parseTodos(items))
+	}
+}
+
+// record adds a snapshot when the list differs from the previous one
+func (t *todoTracker) record(uuid string, timestamp time.Time, todos []models.TodoItem) {
+	var previous []models.TodoItem
+	if len(t.snapshots) > 0 {
+		previous = t.snapshots[len(t.snapshots)-1].Todos
+	}
+	if slices.Equal(previous, todos) {
+		return
+	}
+
+	t.snapshots = append(t.snapshots, models.TodoSnapshot{
+		MessageUUID: uuid,
+		PromptUUID:  t.prompt,
+		Timestamp:   timestamp,
+		Todos:       todos,
+		Changes:     todoChanges(previous, todos),
+	})
+}
+
+// parseTodos decodes todo items, skipping those without content
+func parseTodos(items []interface{}) []models.TodoItem {
+	todos := []models.TodoItem{}
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var todo models.TodoItem
+		todo.Content, _ = fields["content"].(string)
+		todo.Status, _ = fields["status"].(string)
+		todo.ActiveForm, _ = fields["activeForm"].(string)
+		if todo.Content == "" {
+			continue
+		}
+		if todo.Status == "" {
+			todo.Status = todoPending
+		}
+		todos = append(todos, todo)
+	}
+	return todos
+}
+
+// todoChanges lists the items added, removed or moved to another status
+func todoChanges(previous, current []models.TodoItem) []models.TodoChange {
+	before := make(map[string]string)
+	for _, todo := range previous {
+		before[todo.Content] = todo.Status
+	}
+
+	changes := []models.TodoChange{}
+	seen := make(map[string]bool)
+	for _, todo := range current {
+		seen[todo.Content] = true
+		if from, ok := before[todo.Content]; !ok || from != todo.Status {
+			changes = append(changes, models.TodoChange{Content: todo.Content, From: from, To: todo.Status})
+		}
+	}
+	for _, todo := range previous {
+		if !seen[todo.Content] {
+			changes = append(changes, models.TodoChange{Content: todo.Content, From: todo.Status, To: todoRemoved})
+		}
+	}
+	return changes
+}
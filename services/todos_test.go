@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestParseTodos(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []models.TodoItem
+	}{
+		{"empty", `[]`, []models.TodoItem{}},
+		{
+			"statuses and active form",
+			`[{"content":"Write tests","status":"in_progress","activeForm":"Writing tests"},{"content":"Ship","status":"completed"}]`,
+			[]models.TodoItem{
+				{Content: "Write tests", Status: "in_progress", ActiveForm: "Writing tests"},
+				{Content: "Ship", Status: "completed"},
+			},
+		},
+		{"missing status is pending", `[{"content":"Plan"}]`, []models.TodoItem{{Content: "Plan", Status: "pending"}}},
+		{
+			"items without content or not objects are skipped",
+			`[{"status":"pending"},{"content":""},"Plan",3,{"content":"Fix","status":"pending"}]`,
+			[]models.TodoItem{{Content: "Fix", Status: "pending"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []interface{}
+			if err := json.Unmarshal([]byte(tt.input), &items); err != nil {
+				t.Fatal(err)
+			}
+			if got := parseTodos(items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTodos() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSessionTodos(t *testing.T) {
+	todoWrite := func(uuid, parent, todos string) string {
+		return `{"type":"assistant","uuid":"` + uuid + `","parentUuid":"` + parent + `","timestamp":"2026-01-01T10:00:00Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t-` + uuid + `","name":"TodoWrite","input":{"todos":` + todos + `}}]}}`
+	}
+	session := jsonl(
+		`{"type":"user","uuid":"p1","timestamp":"2026-01-01T10:00:00Z","message":{"role":"user","content":"Fix and ship"}}`,
+		todoWrite("a1", "p1", `[{"content":"Fix","status":"pending"},{"content":"Ship","status":"pending"}]`),
+		// Writing the same list again is not a change
+		todoWrite("a2", "a1", `[{"content":"Fix","status":"pending"},{"content":"Ship","status":"pending"}]`),
+		todoWrite("a3", "a2", `[{"content":"Fix","status":"completed"},{"content":"Ship","status":"in_progress"}]`),
+		// Lines without TodoWrite carry the list in their todos field
+		`{"type":"user","uuid":"r1","parentUuid":"a3","timestamp":"2026-01-01T10:00:01Z","todos":[{"content":"Fix","status":"completed"},{"content":"Ship","status":"completed"}],"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t-a3","content":"ok"}]}}`,
+		`{"type":"user","uuid":"r2","parentUuid":"r1","timestamp":"2026-01-01T10:00:02Z","todos":[],"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t-a3","content":"ok"}]}}`,
+		`{"type":"user","uuid":"p2","parentUuid":"r2","timestamp":"2026-01-01T10:01:00Z","message":{"role":"user","content":"Drop the fix"}}`,
+		todoWrite("a4", "p2", `[{"content":"Ship","status":"completed"}]`),
+	)
+	s := newTestService(t, map[string]string{"-tmp-app/s1": session})
+
+	timeline, err := s.GetSessionTodos("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		message, prompt string
+		changes         []models.TodoChange
+	}{
+		{"a1", "p1", []models.TodoChange{{Content: "Fix", To: "pending"}, {Content: "Ship", To: "pending"}}},
+		{"a3", "p1", []models.TodoChange{{Content: "Fix", From: "pending", To: "completed"}, {Content: "Ship", From: "pending", To: "in_progress"}}},
+		{"r1", "p1", []models.TodoChange{{Content: "Ship", From: "in_progress", To: "completed"}}},
+		{"a4", "p2", []models.TodoChange{{Content: "Fix", From: "completed", To: "removed"}}},
+	}
+	if len(timeline.Snapshots) != len(want) {
+		t.Fatalf("got %d snapshots, want %d: %+v", len(timeline.Snapshots), len(want), timeline.Snapshots)
+	}
+	for i, w := range want {
+		snapshot := timeline.Snapshots[i]
+		if snapshot.MessageUUID != w.message || snapshot.PromptUUID != w.prompt {
+			t.Errorf("snapshot %d is of message %s in prompt %s, want %s in %s", i, snapshot.MessageUUID, snapshot.PromptUUID, w.message, w.prompt)
+		}
+		if !reflect.DeepEqual(snapshot.Changes, w.changes) {
+			t.Errorf("snapshot %d changes = %+v, want %+v", i, snapshot.Changes, w.changes)
+		}
+	}
+	if timeline.Planned != 2 || timeline.Completed != 2 {
+		t.Errorf("planned %d, completed %d; want 2 and 2", timeline.Planned, timeline.Completed)
+	}
+}
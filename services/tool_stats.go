@@ -0,0 +1,222 @@
+package services
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// toolStatsLimit is how many commands and files the tool stats keep
+const toolStatsLimit = 30
+
+// readTools and editTools are the tools whose file_path input is counted
+var (
+	readTools = []string{"Read", "NotebookRead"}
+	editTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+)
+
+// GetToolStats aggregates tool calls over the visible projects, or only the
+// one given by encodedPath. When since is set, only calls after it count.
+func (s *SessionService) GetToolStats(since time.Time, encodedPath string) (models.ToolStats, error) {
+	stats := models.ToolStats{Project: encodedPath}
+	if !since.IsZero() {
+		stats.Since = &since
+	}
+
+	tools := newUsageCounter()
+	commands := newUsageCounter()
+	reads := newUsageCounter()
+	edits := newUsageCounter()
+
+	_, err := s.walkSessions(func(project models.Project, info models.SessionInfo, session models.Session) {
+		if encodedPath != "" && project.EncodedPath != encodedPath {
+			return
+		}
+		if !since.IsZero() && info.EndTime.Before(since) {
+			return
+		}
+
+		// Tool results come back in later messages
+		failed := make(map[string]bool)
+		for _, msg := range session.Messages {
+			for _, result := range msg.ToolResults {
+				failed[result.ToolUseID] = result.IsError
+			}
+		}
+
+		for _, msg := range session.Messages {
+			if !since.IsZero() && msg.Timestamp.Before(since) {
+				continue
+			}
+			for _, call := range msg.ToolCalls {
+				isError := failed[call.ID]
+				tools.add(call.Name, "", info, isError)
+
+				if call.Name == "Bash" {
+					command, _ := call.Input["command"].(string)
+					for _, key := range commandKeys(command) {
+						commands.add(key, "", info, isError)
+					}
+				}
+
+				path := toolFilePath(call)
+				if path == "" {
+					continue
+				}
+				path = relativeTo(path, projectRoot(session))
+				switch {
+				case slices.Contains(readTools, call.Name):
+					reads.add(path, project.EncodedPath, info, isError)
+				case slices.Contains(editTools, call.Name):
+					edits.add(path, project.EncodedPath, info, isError)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Tools = tools.top(0)
+	stats.Commands = commands.top(toolStatsLimit)
+	stats.ReadFiles = reads.top(toolStatsLimit)
+	stats.EditedFiles = edits.top(toolStatsLimit)
+
+	return stats, nil
+}
+
+// usageCounter accumulates UsageStats keyed by name and project
+type usageCounter struct {
+	stats    map[string]*models.UsageStat
+	sessions map[string]map[string]*models.SessionRef
+}
+
+func newUsageCounter() *usageCounter {
+	return &usageCounter{
+		stats:    make(map[string]*models.UsageStat),
+		sessions: make(map[string]map[string]*models.SessionRef),
+	}
+}
+
+func (u *usageCounter) add(name, project string, info models.SessionInfo, isError bool) {
+	key := project + "\x00" + name
+	stat, ok := u.stats[key]
+	if !ok {
+		stat = &models.UsageStat{Name: name, Project: project}
+		u.stats[key] = stat
+		u.sessions[key] = make(map[string]*models.SessionRef)
+	}
+
+	ref, ok := u.sessions[key][info.ID]
+	if !ok {
+		title := info.Title
+		if title == "" {
+			title = info.FirstMessage
+		}
+		ref = &models.SessionRef{
+			EncodedPath: info.EncodedPath,
+			SessionID:   info.ID,
+			Title:       title,
+			StartTime:   info.StartTime,
+		}
+		u.sessions[key][info.ID] = ref
+	}
+
+	stat.Count++
+	ref.Count++
+	if isError {
+		stat.Errors++
+		ref.Errors++
+	}
+}
+
+// top returns the most used entries, all of them when limit is 0, each
+// with its sessions by number of uses
+func (u *usageCounter) top(limit int) []models.UsageStat {
+	result := []models.UsageStat{}
+	for key, stat := range u.stats {
+		for _, ref := range u.sessions[key] {
+			stat.Sessions = append(stat.Sessions, *ref)
+		}
+		sort.Slice(stat.Sessions, func(i, j int) bool {
+			if stat.Sessions[i].Count != stat.Sessions[j].Count {
+				return stat.Sessions[i].Count > stat.Sessions[j].Count
+			}
+			return stat.Sessions[i].StartTime.After(stat.Sessions[j].StartTime)
+		})
+		result = append(result, *stat)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+var (
+	commandSeparators = regexp.MustCompile(`&&|\|\||[;|\n]`)
+	envAssignment     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+	subcommandWord    = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+)
+
+// subcommandPrograms are programs whose first argument names what they do
+var subcommandPrograms = []string{
+	"git", "go", "npm", "pnpm", "yarn", "bun", "cargo", "docker", "kubectl", "gh", "make", "pip", "uv", "poetry",
+}
+
+// commandKeys splits a shell command into its pipeline and list parts and
+// returns the program of each, with the subcommand of programs that have
+// one, such as "git commit" or "go test"
+func commandKeys(command string) []string {
+	var keys []string
+	for _, part := range commandSeparators.Split(command, -1) {
+		fields := strings.Fields(part)
+		for len(fields) > 0 && envAssignment.MatchString(fields[0]) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		key := filepath.Base(fields[0])
+		if len(fields) > 1 && slices.Contains(subcommandPrograms, key) && subcommandWord.MatchString(fields[1]) {
+			key += " " + fields[1]
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// toolFilePath returns the file a tool call reads or writes, if any
+func toolFilePath(call models.ToolCall) string {
+	for _, key := range []string{"file_path", "notebook_path"} {
+		if path, ok := call.Input[key].(string); ok && path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// relativeTo returns path relative to dir when it is inside it
+func relativeTo(path, dir string) string {
+	if dir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetToolStats(t *testing.T) {
+	s := newTestService(t, map[string]string{
+		"-tmp-app/s1": forkedSession,
+		"-tmp-web/s2": forkedSession,
+	})
+
+	tests := []struct {
+		name        string
+		since       time.Time
+		project     string
+		reads       int
+		readFiles   int
+		fileProject string
+	}{
+		{"all projects", time.Time{}, "", 4, 4, ""},
+		{"one project", time.Time{}, "-tmp-web", 2, 2, "-tmp-web"},
+		{"after the calls", time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC), "", 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := s.GetToolStats(tt.since, tt.project)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reads := 0
+			for _, tool := range stats.Tools {
+				if tool.Name == "Read" {
+					reads = tool.Count
+				}
+			}
+			if reads != tt.reads {
+				t.Errorf("Read calls = %d, want %d", reads, tt.reads)
+			}
+			if len(stats.ReadFiles) != tt.readFiles {
+				t.Fatalf("read files = %+v, want %d", stats.ReadFiles, tt.readFiles)
+			}
+			for _, file := range stats.ReadFiles {
+				if file.Name != "a.go" && file.Name != "b.go" {
+					t.Errorf("read file %q is not relative to the project", file.Name)
+				}
+				if tt.fileProject != "" && file.Project != tt.fileProject {
+					t.Errorf("read file of project %s, want %s", file.Project, tt.fileProject)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+)
+
+// TrashData represents the structure of the trash index JSON file. The files
+// themselves live in a folder per item under the trash directory.
+type TrashData struct {
+	Items []models.TrashItem `json:"items"`
+}
+
+// trashDir returns the directory holding the files of deleted sessions
+func (s *SessionService) trashDir() string {
+	return filepath.Join(s.dataDir, "trash")
+}
+
+// ListTrash returns the deleted sessions, most recently deleted first
+func (s *SessionService) ListTrash() ([]models.TrashItem, error) {
+	data, err := s.trash.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	items := append([]models.TrashItem{}, data.Items...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// TrashSession deletes a session by moving its JSONL file, its subagent
+// folder and its agent transcripts from the projects folder into the trash
+func (s *SessionService) TrashSession(encodedPath, sessionID string) (models.TrashItem, error) {
+	if s.ReadOnly() {
+		return models.TrashItem{}, fmt.Errorf("cannot delete session: %w", ErrReadOnly)
+	}
+	if !sources.IsPlainName(encodedPath) || !sources.IsPlainName(sessionID) {
+		return models.TrashItem{}, fmt.Errorf("session %s/%s: %w", encodedPath, sessionID, ErrInvalid)
+	}
+
+	projectDir := filepath.Join(s.claudeDir, "projects", encodedPath)
+	sessionFile := filepath.Join(projectDir, sessionID+".jsonl")
+	if _, err := s.source.Stat(encodedPath, sessionID); err != nil {
+		return models.TrashItem{}, fmt.Errorf("session %s: %w", sessionID, ErrNotFound)
+	}
+
+	item := models.TrashItem{
+		ID:          newID(),
+		SessionID:   sessionID,
+		EncodedPath: encodedPath,
+		ProjectPath: s.decodeProjectPath(encodedPath),
+		DeletedAt:   time.Now(),
+	}
+	if info, err := s.getSessionInfo(encodedPath, sessionID, nil); err == nil {
+		item.ProjectPath = info.ProjectPath
+		item.Title = info.FirstMessage
+	}
+
+	originals := []string{sessionFile}
+	if info, err := os.Stat(filepath.Join(projectDir, sessionID)); err == nil && info.IsDir() {
+		originals = append(originals, filepath.Join(projectDir, sessionID))
+	}
+	originals = append(originals, agentTranscripts(projectDir, sessionID)...)
+
+	itemDir := filepath.Join(s.trashDir(), item.ID)
+	if err := os.MkdirAll(itemDir, 0755); err != nil {
+		return models.TrashItem{}, fmt.Errorf("failed to create trash dir: %w", err)
+	}
+
+	// putBack moves back what was already moved so the session stays whole
+	putBack := func() {
+		restored := true
+		for _, moved := range item.Files {
+			if err := s.movePath(filepath.Join(itemDir, moved.Name), moved.Original); err != nil {
+				s.logger.Printf("trash: could not move %s back: %v", moved.Original, err)
+				restored = false
+			}
+		}
+		if restored {
+			os.RemoveAll(itemDir)
+		}
+	}
+
+	for _, original := range originals {
+		file := models.TrashFile{Original: original, Name: filepath.Base(original)}
+		if err := s.movePath(original, filepath.Join(itemDir, file.Name)); err != nil {
+			putBack()
+			return models.TrashItem{}, fmt.Errorf("failed to move %s to trash: %w", original, err)
+		}
+		item.Files = append(item.Files, file)
+	}
+
+	// Files in the trash folder that the index does not list could not be
+	// restored, so the session is only deleted once it is recorded
+	err := s.trash.Update(func(data *TrashData) error {
+		data.Items = append(data.Items, item)
+		return nil
+	})
+	if err != nil {
+		putBack()
+		return models.TrashItem{}, fmt.Errorf("failed to record deleted session: %w", err)
+	}
+	return item, nil
+}
+
+// RestoreTrash moves the files of a deleted session back to where they were.
+// It refuses to overwrite files that have been recreated since.
+func (s *SessionService) RestoreTrash(id string) (models.TrashItem, error) {
+	if s.ReadOnly() {
+		return models.TrashItem{}, fmt.Errorf("cannot restore session: %w", ErrReadOnly)
+	}
+
+	var item models.TrashItem
+	var restored []models.TrashFile
+	itemDir := filepath.Join(s.trashDir(), id)
+	err := s.trash.Update(func(data *TrashData) error {
+		i := slices.IndexFunc(data.Items, func(item models.TrashItem) bool { return item.ID == id })
+		if i < 0 {
+			return fmt.Errorf("trash item %s: %w", id, ErrNotFound)
+		}
+		item = data.Items[i]
+
+		for _, file := range item.Files {
+			if _, err := os.Stat(file.Original); err == nil {
+				return fmt.Errorf("%s: %w", file.Original, ErrConflict)
+			}
+		}
+
+		for _, file := range item.Files {
+			if err := os.MkdirAll(filepath.Dir(file.Original), 0755); err != nil {
+				return err
+			}
+			if err := s.movePath(filepath.Join(itemDir, file.Name), file.Original); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", file.Original, err)
+			}
+			restored = append(restored, file)
+		}
+
+		data.Items = slices.Delete(data.Items, i, i+1)
+		return nil
+	})
+	if err != nil {
+		// Put what was restored back in the trash, so that the item stays
+		// whole and restoring it can be retried
+		for _, file := range restored {
+			if err := s.movePath(file.Original, filepath.Join(itemDir, file.Name)); err != nil {
+				s.logger.Printf("trash: could not move %s back to the trash: %v", file.Original, err)
+			}
+		}
+		return models.TrashItem{}, err
+	}
+	os.RemoveAll(itemDir)
+	return item, nil
+}
+
+// PurgeTrash permanently deletes a session from the trash
+func (s *SessionService) PurgeTrash(id string) error {
+	return s.trash.Update(func(data *TrashData) error {
+		i := slices.IndexFunc(data.Items, func(item models.TrashItem) bool { return item.ID == id })
+		if i < 0 {
+			return fmt.Errorf("trash item %s: %w", id, ErrNotFound)
+		}
+
+		if err := os.RemoveAll(filepath.Join(s.trashDir(), id)); err != nil {
+			return err
+		}
+		data.Items = slices.Delete(data.Items, i, i+1)
+		return nil
+	})
+}
+
+// agentTranscripts returns the agent-*.jsonl files of a project that belong
+// to a session. Their sessionId is the one of the session that started them.
+func agentTranscripts(projectDir, sessionID string) []string {
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "agent-") || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		path := filepath.Join(projectDir, name)
+		if transcriptSessionID(path) == sessionID {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// transcriptSessionID returns the sessionId of the first line carrying one
+func transcriptSessionID(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for i := 0; i < 20; i++ {
+		line, err := reader.ReadBytes('\n')
+		var msg models.JSONLMessage
+		if json.Unmarshal(line, &msg) == nil && msg.SessionID != "" {
+			return msg.SessionID
+		}
+		if err != nil {
+			break
+		}
+	}
+	return ""
+}
+
+// movePath renames a file or folder, copying it when the rename fails because
+// the data dir is on another file system
+func (s *SessionService) movePath(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.CopyFS(dst, os.DirFS(src))
+	} else {
+		err = copyFile(src, dst, info.Mode())
+	}
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if err := os.RemoveAll(src); err != nil {
+		s.logger.Printf("trash: copied %s but could not remove it: %v", src, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
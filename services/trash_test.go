@@ -0,0 +1,120 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/sources"
+)
+
+// newLocalTestService returns a service over a .claude directory on disk
+// holding the given session files, and the directory
+func newLocalTestService(t *testing.T, files map[string]string) (*SessionService, string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, "projects", filepath.FromSlash(name)+".jsonl")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewSessionService(sources.NewDir(root), WithDataDir(filepath.Join(t.TempDir(), "data"))), root
+}
+
+func TestTrashAndRestoreSession(t *testing.T) {
+	s, root := newLocalTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+	sessionFile := filepath.Join(root, "projects", "-tmp-app", "s1.jsonl")
+
+	item, err := s.TrashSession("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sessionFile); !os.IsNotExist(err) {
+		t.Errorf("session file is still in the project after deleting: %v", err)
+	}
+	if items, err := s.ListTrash(); err != nil || len(items) != 1 {
+		t.Errorf("ListTrash() = %v, %v", items, err)
+	}
+
+	if _, err := s.RestoreTrash(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sessionFile); err != nil {
+		t.Errorf("session file was not restored: %v", err)
+	}
+	if items, err := s.ListTrash(); err != nil || len(items) != 0 {
+		t.Errorf("ListTrash() after restoring = %v, %v", items, err)
+	}
+}
+
+func TestTrashSessionPutsFilesBackWhenIndexFails(t *testing.T) {
+	s, root := newLocalTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+	sessionFile := filepath.Join(root, "projects", "-tmp-app", "s1.jsonl")
+
+	// An index written by a newer version cannot be updated
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.trash.Path(), []byte(`{"version":99,"data":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.TrashSession("-tmp-app", "s1"); err == nil {
+		t.Fatal("TrashSession() succeeded without recording the session")
+	}
+	if _, err := os.Stat(sessionFile); err != nil {
+		t.Errorf("session file was not put back: %v", err)
+	}
+	entries, _ := os.ReadDir(s.trashDir())
+	if len(entries) != 0 {
+		t.Errorf("trash folder holds %d unrecorded items", len(entries))
+	}
+}
+
+func TestRestoreTrashPutsFilesBackOnFailure(t *testing.T) {
+	s, root := newLocalTestService(t, map[string]string{
+		"-tmp-app/s1":                forkedSession,
+		"-tmp-app/s1/subagents/task": "{}\n",
+	})
+	sessionFile := filepath.Join(root, "projects", "-tmp-app", "s1.jsonl")
+
+	item, err := s.TrashSession("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Files) != 2 {
+		t.Fatalf("trashed %+v, want the session file and its folder", item.Files)
+	}
+
+	// The folder goes missing from the trash, so restoring fails after the
+	// session file was moved back
+	folder := filepath.Join(s.trashDir(), item.ID, item.Files[1].Name)
+	aside := filepath.Join(t.TempDir(), "folder")
+	if err := os.Rename(folder, aside); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreTrash(item.ID); err == nil {
+		t.Fatal("RestoreTrash() succeeded with a file missing from the trash")
+	}
+	if _, err := os.Stat(sessionFile); !os.IsNotExist(err) {
+		t.Errorf("session file stayed restored after the failure: %v", err)
+	}
+
+	// Once the folder is back, restoring can be retried
+	if err := os.Rename(aside, folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreTrash(item.ID); err != nil {
+		t.Fatalf("retrying RestoreTrash(): %v", err)
+	}
+	if _, err := os.Stat(sessionFile); err != nil {
+		t.Errorf("session file was not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "projects", "-tmp-app", "s1", "subagents", "task.jsonl")); err != nil {
+		t.Errorf("session folder was not restored: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+// GetTurns returns the session grouped into turns, one per human prompt
+func (s *SessionService) GetTurns(encodedPath, sessionID string) ([]models.Turn, error) {
+	session, err := s.GetSession(encodedPath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTurns(session.Messages), nil
+}
+
+// GetTurn returns the turn started by the prompt with the given UUID
+func (s *SessionService) GetTurn(encodedPath, sessionID, promptUUID string) (models.Turn, error) {
+	turns, err := s.GetTurns(encodedPath, sessionID)
+	if err != nil {
+		return models.Turn{}, err
+	}
+	for _, turn := range turns {
+		if turn.Prompt.UUID == promptUUID {
+			return turn, nil
+		}
+	}
+	return models.Turn{}, fmt.Errorf("prompt %s in session %s: %w", promptUUID, sessionID, ErrNotFound)
+}
+
+// BuildTurns groups messages into turns. Each turn starts at a human prompt
+// and contains every message descending from it through the parentUuid
+// chain, up to but not including the next human prompt. Parallel tool calls
+// fork the chain, so the whole subtree is collected and kept in file order.
+func BuildTurns(messages []models.ConversationMessage) []models.Turn {
+	children := make(map[string][]int)
+	for i, msg := range messages {
+		if msg.ParentUUID != "" {
+			children[msg.ParentUUID] = append(children[msg.ParentUUID], i)
+		}
+	}
+
+	var turns []models.Turn
+	for _, prompt := range messages {
+		if !isHumanPrompt(prompt) {
+			continue
+		}
+
+		turn := models.Turn{
+			Prompt:    prompt,
+			StartTime: prompt.Timestamp,
+			EndTime:   prompt.Timestamp,
+		}
+
+		var members []int
+		seen := map[string]bool{prompt.UUID: true}
+		stack := []string{prompt.UUID}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, idx := range children[current] {
+				msg := messages[idx]
+				if isHumanPrompt(msg) || seen[msg.UUID] {
+					continue
+				}
+				seen[msg.UUID] = true
+				members = append(members, idx)
+				stack = append(stack, msg.UUID)
+			}
+		}
+		sort.Ints(members)
+
+		for _, idx := range members {
+			msg := messages[idx]
+			turn.Messages = append(turn.Messages, msg)
+			if msg.Timestamp.After(turn.EndTime) {
+				turn.EndTime = msg.Timestamp
+			}
+		}
+
+		turn.ToolCalls, turn.Response = summarizeTurn(turn.Messages)
+		turns = append(turns, turn)
+	}
+
+	return turns
+}
+
+// summarizeTurn pairs tool calls with their results and joins the assistant text
+func summarizeTurn(messages []models.ConversationMessage) ([]models.ToolCall, string) {
+	results := make(map[string]models.ToolResult)
+	for _, msg := range messages {
+		for _, result := range msg.ToolResults {
+			results[result.ToolUseID] = result
+		}
+	}
+
+	var calls []models.ToolCall
+	var texts []string
+	for _, msg := range messages {
+		if msg.Role != "assistant" {
+			continue
+		}
+		if text := strings.TrimSpace(msg.Content); text != "" {
+			texts = append(texts, text)
+		}
+		for _, call := range msg.ToolCalls {
+			if result, ok := results[call.ID]; ok {
+				call.Result = &result
+			}
+			calls = append(calls, call)
+		}
+	}
+
+	return calls, strings.Join(texts, "\n\n")
+}
+
+// isHumanPrompt reports whether msg is something a person typed and so
+// starts a new turn
+func isHumanPrompt(msg models.ConversationMessage) bool {
+	return msg.Kind.IsHuman()
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/yugo-ibuki/claude-code-prompt-share/models"
+)
+
+func TestGetTurns(t *testing.T) {
+	s := newTestService(t, map[string]string{"-tmp-app/s1": forkedSession})
+
+	turns, err := s.GetTurns("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(turns))
+	}
+
+	first := turns[0]
+	if first.Prompt.UUID != "p1" {
+		t.Errorf("first prompt = %s, want p1", first.Prompt.UUID)
+	}
+	if got := messageUUIDs(first); !slices.Equal(got, []string{"a1", "r1", "r2", "a2"}) {
+		t.Errorf("first turn messages = %v, want both forks in file order", got)
+	}
+	if first.Response != "Looking\n\nDone" {
+		t.Errorf("first response = %q", first.Response)
+	}
+	if len(first.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2", len(first.ToolCalls))
+	}
+	for i, want := range []string{"package a", "package b"} {
+		if result := first.ToolCalls[i].Result; result == nil || result.Content != want {
+			t.Errorf("tool call %d result = %+v, want %q", i, result, want)
+		}
+	}
+	if !first.EndTime.After(first.StartTime) {
+		t.Errorf("first turn ends at %v, not after its start %v", first.EndTime, first.StartTime)
+	}
+
+	if got := messageUUIDs(turns[1]); !slices.Equal(got, []string{"a3"}) {
+		t.Errorf("second turn messages = %v, want [a3]", got)
+	}
+
+	turn, err := s.GetTurn("-tmp-app", "s1", "p2")
+	if err != nil || turn.Response != "You're welcome" {
+		t.Errorf("GetTurn(p2) = %q, %v", turn.Response, err)
+	}
+	if _, err := s.GetTurn("-tmp-app", "s1", "a1"); err == nil {
+		t.Error("GetTurn(a1) succeeded for a message that is not a prompt")
+	}
+}
+
+func messageUUIDs(turn models.Turn) []string {
+	var uuids []string
+	for _, msg := range turn.Messages {
+		uuids = append(uuids, msg.UUID)
+	}
+	return uuids
+}
@@ -0,0 +1,140 @@
+// Package sources reads Claude Code's projects and session files from a
+// .claude directory on the local disk, from any fs.FS, or from a ZIP backup.
+package sources
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// SessionSource is where the viewer reads session files from. Projects are
+// named by their encoded path, the name of their folder under projects/, and
+// sessions by their file name without the .jsonl extension. Errors for
+// projects and sessions that do not exist match fs.ErrNotExist.
+type SessionSource interface {
+	// Projects lists the project folders
+	Projects() ([]string, error)
+	// Sessions lists the session files of a project, agent transcripts included
+	Sessions(encodedPath string) ([]string, error)
+	// Open opens a session file for reading
+	Open(encodedPath, sessionID string) (io.ReadCloser, error)
+	// Stat describes a session file
+	Stat(encodedPath, sessionID string) (fs.FileInfo, error)
+}
+
+// Local is a session source on the local disk, whose files the viewer can
+// move and write next to
+type Local interface {
+	SessionSource
+	// Root returns the .claude directory
+	Root() string
+}
+
+// FS reads session files from a file system whose root is a .claude directory
+type FS struct {
+	fsys fs.FS
+}
+
+// NewFS returns a source reading from fsys
+func NewFS(fsys fs.FS) *FS {
+	return &FS{fsys: fsys}
+}
+
+// Projects lists the project folders, skipping hidden ones
+func (s *FS) Projects() ([]string, error) {
+	entries, err := fs.ReadDir(s.fsys, "projects")
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			projects = append(projects, entry.Name())
+		}
+	}
+	return projects, nil
+}
+
+// Sessions lists the .jsonl files of a project folder, in name order
+func (s *FS) Sessions(encodedPath string) ([]string, error) {
+	dir, err := projectDir(encodedPath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+			sessions = append(sessions, strings.TrimSuffix(entry.Name(), ".jsonl"))
+		}
+	}
+	return sessions, nil
+}
+
+// Open opens a session file
+func (s *FS) Open(encodedPath, sessionID string) (io.ReadCloser, error) {
+	name, err := sessionFile(encodedPath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.fsys.Open(name)
+}
+
+// Stat describes a session file
+func (s *FS) Stat(encodedPath, sessionID string) (fs.FileInfo, error) {
+	name, err := sessionFile(encodedPath, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(s.fsys, name)
+}
+
+// Dir reads session files from a .claude directory on the local disk
+type Dir struct {
+	FS
+	root string
+}
+
+// NewDir returns a source reading from the .claude directory at root
+func NewDir(root string) *Dir {
+	return &Dir{FS: FS{fsys: os.DirFS(root)}, root: root}
+}
+
+// Root returns the .claude directory
+func (d *Dir) Root() string {
+	return d.root
+}
+
+// projectDir returns the path of a project folder in the file system. A name
+// that is not a single path element names no project.
+func projectDir(encodedPath string) (string, error) {
+	if !IsPlainName(encodedPath) {
+		return "", &fs.PathError{Op: "open", Path: encodedPath, Err: fs.ErrNotExist}
+	}
+	return path.Join("projects", encodedPath), nil
+}
+
+func sessionFile(encodedPath, sessionID string) (string, error) {
+	dir, err := projectDir(encodedPath)
+	if err != nil {
+		return "", err
+	}
+	if !IsPlainName(sessionID) {
+		return "", &fs.PathError{Op: "open", Path: sessionID, Err: fs.ErrNotExist}
+	}
+	return path.Join(dir, sessionID+".jsonl"), nil
+}
+
+// IsPlainName reports whether name is a single path element, so that it
+// cannot point outside the folder it is joined to
+func IsPlainName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
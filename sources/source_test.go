@@ -0,0 +1,87 @@
+package sources
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"projects/-tmp-app/s1.jsonl":       {Data: []byte(`{"type":"user"}` + "\n")},
+		"projects/-tmp-app/agent-a1.jsonl": {Data: []byte("{}\n")},
+		"projects/-tmp-app/notes.txt":      {Data: []byte("not a session")},
+		"projects/-tmp-app/sub/s9.jsonl":   {Data: []byte("{}\n")},
+		"projects/-tmp-other/s2.jsonl":     {Data: []byte("{}\n")},
+		"projects/.hidden/s3.jsonl":        {Data: []byte("{}\n")},
+		"projects/file.jsonl":              {Data: []byte("{}\n")},
+		"secret.jsonl":                     {Data: []byte("{}\n")},
+	}
+}
+
+func TestFSListsProjectsAndSessions(t *testing.T) {
+	source := NewFS(testFS())
+
+	projects, err := source.Projects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-tmp-app", "-tmp-other"}; !slices.Equal(projects, want) {
+		t.Errorf("Projects() = %v, want %v", projects, want)
+	}
+
+	sessions, err := source.Sessions("-tmp-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"agent-a1", "s1"}; !slices.Equal(sessions, want) {
+		t.Errorf("Sessions() = %v, want %v", sessions, want)
+	}
+
+	if _, err := source.Sessions("-tmp-missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Sessions() of a missing project: %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestFSOpensSessions(t *testing.T) {
+	source := NewFS(testFS())
+
+	file, err := source.Open("-tmp-app", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(data) != `{"type":"user"}`+"\n" {
+		t.Errorf("Open() read %q, %v", data, err)
+	}
+
+	info, err := source.Stat("-tmp-app", "s1")
+	if err != nil || info.Size() != int64(len(data)) {
+		t.Errorf("Stat() = %v, %v", info, err)
+	}
+}
+
+func TestFSRejectsPathsOutsideProjects(t *testing.T) {
+	source := NewFS(testFS())
+
+	for _, tt := range []struct{ project, session string }{
+		{"-tmp-app", "missing"},
+		{"-tmp-app", "../../secret"},
+		{"-tmp-app", "sub/s9"},
+		{"..", "secret"},
+		{".", "file"},
+		{"", "s1"},
+		{"-tmp-app", ""},
+	} {
+		if _, err := source.Open(tt.project, tt.session); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%q, %q): %v, want fs.ErrNotExist", tt.project, tt.session, err)
+		}
+		if _, err := source.Stat(tt.project, tt.session); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%q, %q): %v, want fs.ErrNotExist", tt.project, tt.session, err)
+		}
+	}
+}
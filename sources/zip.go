@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// Zip reads session files from a ZIP backup of a .claude directory. The
+// archive may hold the directory itself or only its content.
+type Zip struct {
+	FS
+	reader *zip.ReadCloser
+}
+
+// OpenZip opens a ZIP backup of a .claude directory
+func OpenZip(name string) (*Zip, error) {
+	reader, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, root := range []string{".", ".claude"} {
+		if info, err := fs.Stat(reader, path.Join(root, "projects")); err != nil || !info.IsDir() {
+			continue
+		}
+		fsys, err := fs.Sub(reader, root)
+		if err != nil {
+			break
+		}
+		return &Zip{FS: FS{fsys: fsys}, reader: reader}, nil
+	}
+
+	reader.Close()
+	return nil, fmt.Errorf("%s has no projects folder at its root or under .claude", name)
+}
+
+// Close closes the archive
+func (z *Zip) Close() error {
+	return z.reader.Close()
+}
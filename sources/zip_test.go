@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeZip writes an archive holding the given files
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "backup.zip")
+	out, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for path, content := range files {
+		f, err := w.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(f, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestOpenZip(t *testing.T) {
+	for _, prefix := range []string{"", ".claude/"} {
+		t.Run("root "+prefix, func(t *testing.T) {
+			name := writeZip(t, map[string]string{
+				prefix + "projects/-tmp-app/s1.jsonl": "{}\n",
+				prefix + "projects/-tmp-app/s2.jsonl": "{}\n{}\n",
+				prefix + "settings.json":              "{}",
+			})
+
+			source, err := OpenZip(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer source.Close()
+
+			projects, err := source.Projects()
+			if err != nil || !slices.Equal(projects, []string{"-tmp-app"}) {
+				t.Errorf("Projects() = %v, %v", projects, err)
+			}
+			sessions, err := source.Sessions("-tmp-app")
+			if err != nil || !slices.Equal(sessions, []string{"s1", "s2"}) {
+				t.Errorf("Sessions() = %v, %v", sessions, err)
+			}
+
+			file, err := source.Open("-tmp-app", "s2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(file)
+			file.Close()
+			if err != nil || string(data) != "{}\n{}\n" {
+				t.Errorf("Open() read %q, %v", data, err)
+			}
+
+			// A ZIP backup is not a local directory the viewer can write to
+			var s SessionSource = source
+			if _, ok := s.(Local); ok {
+				t.Error("a ZIP source is Local")
+			}
+		})
+	}
+}
+
+func TestOpenZipWithoutProjects(t *testing.T) {
+	name := writeZip(t, map[string]string{"other/projects/-tmp-app/s1.jsonl": "{}\n"})
+
+	if source, err := OpenZip(name); err == nil {
+		source.Close()
+		t.Error("OpenZip() succeeded for an archive without a projects folder at its root or under .claude")
+	}
+	if _, err := OpenZip(filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("OpenZip() succeeded for a missing file")
+	}
+}
@@ -0,0 +1,8 @@
+// Helpers shared by the pages
+
+// escapeHtml escapes text for element content and quoted attributes
+function escapeHtml(text) {
+    return String(text ?? '').replace(/[&<>"']/g, c => ({
+        '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'
+    })[c])
+}
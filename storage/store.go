@@ -0,0 +1,202 @@
+// Package storage persists the viewer's own user state (archives, titles,
+// tags, the prompt library) as JSON documents in the data directory.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// envelope is the on-disk format of a document. Files written before
+// versioning was introduced hold the bare document and are read as version 0.
+type envelope struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Store is a JSON document of type T stored in a single file. Reads and
+// updates are serialized by a mutex, writes go to a temporary file that is
+// renamed over the original, and the previous version is kept as a .bak file
+// to recover from corruption.
+type Store[T any] struct {
+	path    string
+	version int
+	logger  *log.Logger
+	mu      sync.Mutex
+}
+
+// Option configures a Store
+type Option func(*options)
+
+type options struct {
+	logger *log.Logger
+}
+
+// WithLogger logs the files the store recovers from to logger instead of the
+// standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// New returns a store for the document at path with the given schema version
+func New[T any](path string, version int, opts ...Option) *Store[T] {
+	o := options{logger: log.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Store[T]{path: path, version: version, logger: o.logger}
+}
+
+// Path returns the file the store writes to
+func (s *Store[T]) Path() string {
+	return s.path
+}
+
+// Read returns the stored document, or the zero value if none exists yet
+func (s *Store[T]) Read() (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Update loads the document, applies fn and writes the result back. The
+// document is not written if fn returns an error.
+func (s *Store[T]) Update(fn func(*T) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(&data); err != nil {
+		return err
+	}
+	return s.save(data)
+}
+
+// load reads the document, falling back to the backup when the file is
+// missing or corrupt. A corrupt file is moved aside rather than overwritten.
+func (s *Store[T]) load() (T, error) {
+	var zero T
+
+	data, err := s.decodeFile(s.path)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		if _, ok := err.(*versionError); ok {
+			return zero, err
+		}
+		corrupt := fmt.Sprintf("%s.corrupt-%d", s.path, time.Now().Unix())
+		s.logger.Printf("storage: %s is unreadable (%v), moved to %s", s.path, err, corrupt)
+		if renameErr := os.Rename(s.path, corrupt); renameErr != nil {
+			return zero, fmt.Errorf("failed to move aside corrupt %s: %w", s.path, renameErr)
+		}
+	}
+
+	data, bakErr := s.decodeFile(s.path + ".bak")
+	if bakErr == nil {
+		if !os.IsNotExist(err) {
+			s.logger.Printf("storage: restored %s from backup", s.path)
+		}
+		return data, nil
+	}
+	return zero, nil
+}
+
+func (s *Store[T]) decodeFile(path string) (T, error) {
+	var data T
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return data, err
+	}
+
+	// Files without the envelope predate versioning and hold the bare document
+	payload := raw
+	if _, ok := fields["version"]; ok {
+		var env envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return data, err
+		}
+		if env.Version > s.version {
+			return data, &versionError{path: path, found: env.Version, supported: s.version}
+		}
+		payload = env.Data
+	}
+
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// save writes the document atomically and keeps the previous file as backup
+func (s *Store[T]) save(data T) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(envelope{Version: s.version, Data: payload}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.path); err == nil {
+		if err := os.Rename(s.path, s.path+".bak"); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// versionError is returned for files written by a newer schema version,
+// which must not be overwritten
+type versionError struct {
+	path      string
+	found     int
+	supported int
+}
+
+func (e *versionError) Error() string {
+	return fmt.Sprintf("%s has schema version %d, newer than supported version %d", e.path, e.found, e.supported)
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type doc struct {
+	Items []string `json:"items"`
+}
+
+func addItem(item string) func(*doc) error {
+	return func(d *doc) error {
+		d.Items = append(d.Items, item)
+		return nil
+	}
+}
+
+func TestStoreWritesVersionedEnvelope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	store := New[doc](path, 2)
+
+	if data, err := store.Read(); err != nil || data.Items != nil {
+		t.Fatalf("Read() before any write = %+v, %v; want the zero value", data, err)
+	}
+	if err := store.Update(addItem("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env struct {
+		Version int `json:"version"`
+		Data    doc `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Version != 2 || len(env.Data.Items) != 1 || env.Data.Items[0] != "a" {
+		t.Errorf("file holds %s", raw)
+	}
+}
+
+func TestStoreReadsBareDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	if err := os.WriteFile(path, []byte(`{"items":["old"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := New[doc](path, 1).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 1 || data.Items[0] != "old" {
+		t.Errorf("Read() = %+v, want the document written before versioning", data)
+	}
+}
+
+func TestStoreRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	newer := []byte(`{"version":3,"data":{"items":["new"]}}`)
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := New[doc](path, 2)
+	var versionErr *versionError
+	if _, err := store.Read(); !errors.As(err, &versionErr) {
+		t.Errorf("Read() error = %v, want a version error", err)
+	}
+	if err := store.Update(addItem("a")); !errors.As(err, &versionErr) {
+		t.Errorf("Update() error = %v, want a version error", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != string(newer) {
+		t.Errorf("the newer file was overwritten with %s", raw)
+	}
+}
+
+func TestStoreRecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.json")
+	var logs bytes.Buffer
+	store := New[doc](path, 1, WithLogger(log.New(&logs, "", 0)))
+
+	// The second write keeps the first as backup
+	if err := store.Update(addItem("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update(addItem("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"version":1,"data":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := store.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 1 || data.Items[0] != "a" {
+		t.Errorf("Read() = %+v, want the backup holding [a]", data)
+	}
+
+	if !strings.Contains(logs.String(), "restored "+path+" from backup") {
+		t.Errorf("recovery was not logged, got %q", logs.String())
+	}
+
+	corrupt, _ := filepath.Glob(path + ".corrupt-*")
+	if len(corrupt) != 1 {
+		t.Errorf("corrupt file was not moved aside, found %v", corrupt)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("corrupt file is still at %s", path)
+	}
+
+	// Updating starts from the backup and writes a good file again
+	if err := store.Update(addItem("c")); err != nil {
+		t.Fatal(err)
+	}
+	data, err = store.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 2 || data.Items[1] != "c" {
+		t.Errorf("Read() after update = %+v, want [a c]", data)
+	}
+}